@@ -29,10 +29,17 @@ package main
 import (
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/lakhan-purohit/net-http/internal/pkg/config"
 	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/pkg/lockout"
+	"github.com/lakhan-purohit/net-http/internal/pkg/mailer"
+	"github.com/lakhan-purohit/net-http/internal/pkg/revocation"
 	"github.com/lakhan-purohit/net-http/internal/pkg/server"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/service"
 )
 
 func main() {
@@ -51,9 +58,47 @@ func main() {
 
 	slog.Info("Starting Golang API", "env", cfg.App.Env, "port", cfg.App.Port)
 
+	// 🔥 JWT signing/verification keys
+	if _, err := utils.LoadJWTKeys(); err != nil {
+		slog.Error("invalid jwt key configuration", "error", err)
+		os.Exit(1)
+	}
+	if cfg.JWT.AcceptHS256 && cfg.JWT.Algorithm != utils.AlgHS256 {
+		slog.Warn("jwt_accepting_hs256", "algorithm", cfg.JWT.Algorithm, "hint", "unset JWT_ACCEPT_HS256 once HS256 tokens have expired")
+	}
+
+	// 🔥 Mail delivery
+	m, err := mailer.New(cfg.Mail)
+	if err != nil {
+		slog.Error("invalid mail configuration", "error", err)
+		os.Exit(1)
+	}
+	mailer.SetDefault(m)
+
 	// 🔥 Connect DB
 	db.Connect(cfg.DB)
 
+	// 🔥 Token revocation store (shared between instances when backed by MySQL)
+	if cfg.JWT.RevocationStore == "mysql" {
+		revocation.SetDefault(revocation.NewMySQLStore(db.DB, 10*time.Minute))
+	}
+
+	// 🔥 Brute-force protection (counters shared between instances when backed by MySQL)
+	if cfg.Lockout.Store == "mysql" {
+		lockout.SetDefault(lockout.NewGuard(
+			lockout.NewMySQLStore(db.DB, 10*time.Minute, cfg.Lockout.Window),
+			cfg.Lockout,
+		))
+	}
+
+	// 🔥 Hard-delete accounts once they can no longer be restored
+	if cfg.Account.DeletedRetention > 0 {
+		go service.RunUserPurge(repository.NewUserRepository(db.DB), cfg.Account)
+	}
+
+	// 🔥 Lift temporary bans and suspensions once they run out
+	go service.RunStatusExpiry(repository.NewUserRepository(db.DB), cfg.Account)
+
 	// 🔥 Graceful shutdown
 	server.Run()
 
@@ -0,0 +1,86 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+)
+
+// Keys look like "ak_<prefix>_<secret>". The prefix is stored in clear and
+// used for lookup; only the SHA-256 of the whole key is stored, which is
+// enough because the secret is 256 bits of randomness.
+const (
+	keyScheme    = "ak"
+	prefixBytes  = 6
+	secretBytes  = 32
+	touchEvery   = time.Minute // minimum interval between last_used_at writes per key
+	defaultCache = time.Minute
+)
+
+// Scopes a key can be granted. Each one opens a route group to the key; the
+// user must still be authorized by their own token where the group needs it.
+const (
+	ScopeAll   = "*"     // every route group
+	ScopeAuth  = "auth"  // /api/v1/public/auth
+	ScopeUser  = "user"  // /api/v1/private/user
+	ScopeAdmin = "admin" // /api/v1/private/admin
+)
+
+// Key is the stored form of an API key
+type Key struct {
+	ID        int64
+	Prefix    string
+	Hash      string
+	OwnerID   int64
+	Scopes    []string
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+// HasScope reports whether the key grants scope ("*" grants everything)
+func (k *Key) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, ScopeAll) || slices.Contains(k.Scopes, scope)
+}
+
+// Store looks keys up by prefix. FindByPrefix returns nil, nil if there is no such key.
+type Store interface {
+	FindByPrefix(ctx context.Context, prefix string) (*Key, error)
+	TouchLastUsed(ctx context.Context, id int64, at time.Time) error
+}
+
+// Generate creates a new random key. The plaintext is returned to the caller
+// once and never stored.
+func Generate() (plaintext, prefix, hash string, err error) {
+	p := make([]byte, prefixBytes)
+	s := make([]byte, secretBytes)
+	if _, err = rand.Read(p); err != nil {
+		return "", "", "", err
+	}
+	if _, err = rand.Read(s); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(p)
+	plaintext = keyScheme + "_" + prefix + "_" + hex.EncodeToString(s)
+	return plaintext, prefix, Hash(plaintext), nil
+}
+
+// Hash returns the hex SHA-256 of a plaintext key
+func Hash(plaintext string) string {
+	return utils.HashSecret(plaintext)
+}
+
+// parse extracts the lookup prefix from a plaintext key
+func parse(plaintext string) (string, bool) {
+	parts := strings.Split(plaintext, "_")
+	if len(parts) != 3 || parts[0] != keyScheme ||
+		len(parts[1]) != prefixBytes*2 || len(parts[2]) != secretBytes*2 {
+		return "", false
+	}
+	return parts[1], true
+}
@@ -0,0 +1,68 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+)
+
+// MySQLStore reads keys from the api_keys table
+type MySQLStore struct {
+	db *sql.DB
+}
+
+func NewMySQLStore(db *sql.DB) *MySQLStore {
+	return &MySQLStore{db: db}
+}
+
+// FindByPrefix only finds keys whose owner could sign in right now, so keys of
+// deleted, banned or suspended accounts stop working without being revoked
+// (once the validator cache entry expires, see API_KEY_CACHE_TTL).
+// The status rule mirrors the one applied at login.
+func (s *MySQLStore) FindByPrefix(ctx context.Context, prefix string) (*Key, error) {
+	var (
+		k      Key
+		scopes string
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT k.id, k.prefix, k.key_hash, k.owner_id, k.scopes, k.expires_at, k.revoked_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.owner_id AND u.deleted_at IS NULL
+		WHERE k.prefix = ?
+			AND (u.status = ? OR u.status_until <= NOW())
+	`, prefix, constants.UserStatusActive).Scan(&k.ID, &k.Prefix, &k.Hash, &k.OwnerID, &scopes, &k.ExpiresAt, &k.RevokedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	k.Scopes = SplitScopes(scopes)
+	return &k, nil
+}
+
+func (s *MySQLStore) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = ? WHERE id = ?", at, id)
+	return err
+}
+
+// JoinScopes and SplitScopes convert between a scope list and the
+// comma-separated form stored in api_keys.scopes
+func JoinScopes(scopes []string) string {
+	return strings.Join(scopes, ",")
+}
+
+func SplitScopes(s string) []string {
+	scopes := []string{}
+	for _, scope := range strings.Split(s, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
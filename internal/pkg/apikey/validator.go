@@ -0,0 +1,162 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+)
+
+type cacheEntry struct {
+	key       *Key
+	fetchedAt time.Time
+	touchedAt time.Time
+}
+
+// Validator checks presented keys against a Store, caching lookups in
+// process for cacheTTL. A revoked key may therefore keep working on other
+// instances for up to cacheTTL; Invalidate drops it locally at once.
+type Validator struct {
+	store    Store
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+}
+
+func NewValidator(store Store, cacheTTL time.Duration) *Validator {
+	v := &Validator{
+		store:    store,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]*cacheEntry),
+	}
+	go v.janitor()
+	return v
+}
+
+// Validate returns the key matching plaintext, or an apperr describing why it is not accepted
+func (v *Validator) Validate(ctx context.Context, plaintext string) (*Key, error) {
+	prefix, ok := parse(plaintext)
+	if !ok {
+		return nil, apperr.ErrInvalidAPIKey
+	}
+
+	entry, err := v.lookup(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, apperr.ErrInvalidAPIKey
+	}
+
+	k := entry.key
+	if subtle.ConstantTimeCompare([]byte(k.Hash), []byte(Hash(plaintext))) != 1 {
+		return nil, apperr.ErrInvalidAPIKey
+	}
+	if k.RevokedAt != nil {
+		return nil, apperr.ErrAPIKeyRevoked
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, apperr.ErrAPIKeyExpired
+	}
+
+	v.touch(entry)
+	return k, nil
+}
+
+// Invalidate drops a cached key, e.g. right after it was revoked
+func (v *Validator) Invalidate(prefix string) {
+	v.mu.Lock()
+	delete(v.cache, prefix)
+	v.mu.Unlock()
+}
+
+func (v *Validator) lookup(ctx context.Context, prefix string) (*cacheEntry, error) {
+	v.mu.Lock()
+	entry, ok := v.cache[prefix]
+	v.mu.Unlock()
+
+	if ok && time.Since(entry.fetchedAt) < v.cacheTTL {
+		return entry, nil
+	}
+
+	k, err := v.store.FindByPrefix(ctx, prefix)
+	if err != nil || k == nil {
+		return nil, err
+	}
+
+	entry = &cacheEntry{key: k, fetchedAt: time.Now()}
+	v.mu.Lock()
+	v.cache[prefix] = entry
+	v.mu.Unlock()
+	return entry, nil
+}
+
+// touch records usage at most once per touchEvery per key, off the request path
+func (v *Validator) touch(entry *cacheEntry) {
+	now := time.Now()
+
+	v.mu.Lock()
+	if now.Sub(entry.touchedAt) < touchEvery {
+		v.mu.Unlock()
+		return
+	}
+	entry.touchedAt = now
+	v.mu.Unlock()
+
+	go func(id int64) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := v.store.TouchLastUsed(ctx, id, now); err != nil {
+			slog.Error("api_key_touch_failed", "key_id", id, "error", err)
+		}
+	}(entry.key.ID)
+}
+
+func (v *Validator) janitor() {
+	ticker := time.NewTicker(v.cacheTTL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		v.mu.Lock()
+		for prefix, entry := range v.cache {
+			if time.Since(entry.fetchedAt) >= v.cacheTTL {
+				delete(v.cache, prefix)
+			}
+		}
+		v.mu.Unlock()
+	}
+}
+
+var (
+	defaultValidator *Validator
+	defaultMu        sync.Mutex
+)
+
+// Default returns the validator used by the API key middleware. Unless
+// SetDefault was called it is backed by the api_keys table.
+func Default() *Validator {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultValidator == nil {
+		ttl := config.Get().APIKey.CacheTTL
+		if ttl <= 0 {
+			ttl = defaultCache
+		}
+		defaultValidator = NewValidator(NewMySQLStore(db.DB), ttl)
+	}
+	return defaultValidator
+}
+
+// SetDefault replaces the validator used by the API key middleware
+func SetDefault(v *Validator) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultValidator = v
+}
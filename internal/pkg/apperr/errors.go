@@ -8,14 +8,22 @@ import (
 // AppError represents a professional application error
 type AppError struct {
 	Status  int    `json:"-"`
-	Message string `json:"m"`    // Mapped to m in response
-	Code    string `json:"c"`    // Mapped to c in response
+	Message string `json:"m"` // Mapped to m in response
+	Code    string `json:"c"` // Mapped to c in response
+	Details any    `json:"-"` // Sent as the response result, e.g. per-rule violations
 }
 
 func (e *AppError) Error() string {
 	return fmt.Sprintf("[%d] %s: %s", e.Status, e.Code, e.Message)
 }
 
+// WithDetails returns a copy of the error carrying details for the client
+func (e *AppError) WithDetails(details any) *AppError {
+	c := *e
+	c.Details = details
+	return &c
+}
+
 // Common errors
 func New(status int, message string, code string) *AppError {
 	return &AppError{
@@ -26,8 +34,92 @@ func New(status int, message string, code string) *AppError {
 }
 
 var (
-	ErrNotFound      = New(http.StatusNotFound, "Resource not found", "NOT_FOUND")
-	ErrUnauthorized  = New(http.StatusUnauthorized, "Unauthorized access", "UNAUTHORIZED")
-	ErrBadRequest    = New(http.StatusBadRequest, "Invalid request", "BAD_REQUEST")
-	ErrInternal      = New(http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+	ErrNotFound     = New(http.StatusNotFound, "Resource not found", "NOT_FOUND")
+	ErrUnauthorized = New(http.StatusUnauthorized, "Unauthorized access", "UNAUTHORIZED")
+	ErrBadRequest   = New(http.StatusBadRequest, "Invalid request", "BAD_REQUEST")
+	ErrInternal     = New(http.StatusInternalServerError, "Internal server error", "INTERNAL_ERROR")
+)
+
+// Authentication errors
+var (
+	ErrInvalidCredentials = New(http.StatusUnauthorized, "Invalid credentials", "INVALID_CREDENTIALS")
+	ErrLoginThrottled     = New(http.StatusTooManyRequests, "Too many failed login attempts, try again later", "LOGIN_THROTTLED")
+	ErrAccountLocked      = New(http.StatusTooManyRequests, "Account temporarily locked after too many failed attempts", "ACCOUNT_LOCKED")
+	ErrInvalidCode        = New(http.StatusBadRequest, "Invalid or expired code", "INVALID_CODE")
+	ErrPasswordTooLong    = New(http.StatusBadRequest, "Password must be at most 72 bytes long", "PASSWORD_TOO_LONG")
+	ErrPasswordPolicy     = New(http.StatusBadRequest, "Password does not meet the password policy", "PASSWORD_POLICY")
+)
+
+// Account errors
+var (
+	ErrInvalidCurrentPassword = New(http.StatusBadRequest, "Current password is incorrect", "INVALID_CURRENT_PASSWORD")
+	ErrEmailTaken             = New(http.StatusConflict, "Email address is already in use", "EMAIL_TAKEN")
+	ErrEmailUnchanged         = New(http.StatusBadRequest, "New email address is the same as the current one", "EMAIL_UNCHANGED")
+)
+
+// Two-factor authentication errors
+var (
+	ErrInvalidMFACode    = New(http.StatusUnauthorized, "Invalid two-factor code", "INVALID_MFA_CODE")
+	ErrMFAAlreadyEnabled = New(http.StatusConflict, "Two-factor authentication is already enabled", "MFA_ALREADY_ENABLED")
+	ErrMFANotSetUp       = New(http.StatusBadRequest, "Start two-factor setup first", "MFA_NOT_SET_UP")
+)
+
+// Account status errors (see constants.UserStatus*)
+var (
+	ErrAccountPending   = New(http.StatusForbidden, "Please verify your email address before logging in", "ACCOUNT_PENDING")
+	ErrAccountInactive  = New(http.StatusForbidden, "This account is inactive", "ACCOUNT_INACTIVE")
+	ErrAccountBanned    = New(http.StatusForbidden, "This account has been banned", "ACCOUNT_BANNED")
+	ErrAccountSuspended = New(http.StatusForbidden, "This account is suspended", "ACCOUNT_SUSPENDED")
+
+	ErrInvalidStatusTransition = New(http.StatusConflict, "The account cannot be moved to this status from its current one", "INVALID_STATUS_TRANSITION")
+	ErrStatusChangeNotAllowed  = New(http.StatusForbidden, "You cannot change the status of your own account", "STATUS_CHANGE_NOT_ALLOWED")
+)
+
+// Authorization errors
+var (
+	ErrInsufficientRole = New(http.StatusForbidden, "Your role does not allow this action", "INSUFFICIENT_ROLE")
+	ErrPermissionDenied = New(http.StatusForbidden, "You do not have permission to perform this action", "PERMISSION_DENIED")
+)
+
+// API key errors
+var (
+	ErrInvalidAPIKey = New(http.StatusUnauthorized, "Invalid API key", "INVALID_API_KEY")
+	ErrAPIKeyRevoked = New(http.StatusUnauthorized, "API key has been revoked", "API_KEY_REVOKED")
+	ErrAPIKeyExpired = New(http.StatusUnauthorized, "API key has expired", "API_KEY_EXPIRED")
+	ErrAPIKeyScope   = New(http.StatusForbidden, "API key is not allowed to call this route", "API_KEY_SCOPE")
+)
+
+// Request signing errors
+var (
+	ErrSignatureMissing  = New(http.StatusUnauthorized, "Request signature headers are missing", "SIGNATURE_MISSING")
+	ErrUnknownClient     = New(http.StatusUnauthorized, "Unknown signing client", "UNKNOWN_CLIENT")
+	ErrSignatureExpired  = New(http.StatusUnauthorized, "Request timestamp is outside the allowed window", "SIGNATURE_EXPIRED")
+	ErrInvalidSignature  = New(http.StatusUnauthorized, "Invalid request signature", "INVALID_SIGNATURE")
+	ErrSignatureReplayed = New(http.StatusUnauthorized, "Request nonce has already been used", "SIGNATURE_REPLAYED")
+	ErrBodyTooLarge      = New(http.StatusRequestEntityTooLarge, "Request body too large to sign", "BODY_TOO_LARGE")
+)
+
+// Token errors
+var (
+	ErrInvalidToken      = New(http.StatusUnauthorized, "Invalid or expired token", "INVALID_TOKEN")
+	ErrInvalidTokenType  = New(http.StatusUnauthorized, "Token type not accepted here", "INVALID_TOKEN_TYPE")
+	ErrTokenRevoked      = New(http.StatusUnauthorized, "Token has been revoked", "TOKEN_REVOKED")
+	ErrTokenReused       = New(http.StatusUnauthorized, "Refresh token reuse detected, session revoked", "TOKEN_REUSED")
+	ErrInsufficientScope = New(http.StatusForbidden, "Token scope does not allow this action", "INSUFFICIENT_SCOPE")
+)
+
+// External (OIDC) sign-in errors
+var (
+	ErrUnknownProvider         = New(http.StatusNotFound, "Unknown identity provider", "UNKNOWN_PROVIDER")
+	ErrInvalidLoginState       = New(http.StatusBadRequest, "Sign-in request is invalid or has expired", "INVALID_LOGIN_STATE")
+	ErrExternalLoginFailed     = New(http.StatusUnauthorized, "Sign-in with the identity provider failed", "EXTERNAL_LOGIN_FAILED")
+	ErrExternalEmailUnverified = New(http.StatusForbidden, "The identity provider has not verified your email address", "EXTERNAL_EMAIL_UNVERIFIED")
+	ErrProviderUnavailable     = New(http.StatusBadGateway, "Identity provider is unavailable", "PROVIDER_UNAVAILABLE")
+	ErrAccountUnavailable      = New(http.StatusConflict, "No account can be created for this email address", "ACCOUNT_UNAVAILABLE")
+)
+
+// Impersonation errors
+var (
+	ErrImpersonationNotAllowed = New(http.StatusForbidden, "This user cannot be impersonated", "IMPERSONATION_NOT_ALLOWED")
+	ErrImpersonationForbidden  = New(http.StatusForbidden, "Not available while impersonating a user", "IMPERSONATION_FORBIDDEN")
 )
@@ -5,20 +5,33 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
-	App AppConfig
-	DB  DBConfig
-	JWT JWTConfig
+	App        AppConfig
+	DB         DBConfig
+	JWT        JWTConfig
+	Lockout    LockoutConfig
+	Mail       MailConfig
+	Auth       AuthConfig
+	APIKey     APIKeyConfig
+	ClientAuth ClientAuthConfig
+	OIDC       OIDCConfig
+	Password   PasswordConfig
+	Account    AccountConfig
 }
 
 type AppConfig struct {
 	Env  string
 	Port string
+
+	// TrustedProxies lists the IPs/CIDRs whose X-Forwarded-For / X-Real-IP headers are honoured
+	TrustedProxies []string
 }
 
 type DBConfig struct {
@@ -34,8 +47,135 @@ type DBConfig struct {
 
 type JWTConfig struct {
 	Secret            string
+	Algorithm         string   // HS256, RS256, ES256 or EdDSA
+	PrivateKeyFile    string   // PEM signing key, required unless Algorithm is HS256
+	PublicKeyFiles    []string // PEM keys of previous signing keys, still accepted for verification
+	AcceptHS256       bool     // opt-in grace period: keep accepting HS256 tokens after moving to an asymmetric algorithm
+	Issuer            string   // iss of issued tokens; when set, tokens from other issuers are rejected
+	Audience          string   // aud of issued tokens; when set, tokens for other audiences are rejected
+	Leeway            time.Duration
 	AccessExpiration  time.Duration
 	RefreshExpiration time.Duration
+	MFAExpiration     time.Duration // lifetime of the mfa_pending token
+	ImpersonationTTL  time.Duration // lifetime of admin impersonation tokens
+	RevocationStore   string        // "memory" or "mysql"
+}
+
+// LockoutConfig controls brute-force protection on login
+type LockoutConfig struct {
+	Store          string // "memory" or "mysql"
+	FreeAttempts   int    // per-account failures before backoff starts
+	MaxAttempts    int    // per-account failures before the account is locked
+	IPFreeAttempts int
+	IPMaxAttempts  int // per-IP failures before the IP is blocked for Window
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	Window         time.Duration // failures older than this are forgotten
+	LockDuration   time.Duration
+}
+
+type MailConfig struct {
+	Driver       string // "log", "file" or "smtp"
+	From         string
+	FileDir      string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// AuthConfig holds the lifetimes of the one-time codes used by account flows
+type AuthConfig struct {
+	PasswordResetTTL          time.Duration
+	PasswordResetRateLimit    int // reset codes sent per address within PasswordResetRateWindow
+	PasswordResetRateWindow   time.Duration
+	EmailVerificationTTL      time.Duration
+	EmailVerificationCooldown time.Duration
+	MFAIssuer                 string // shown in authenticator apps
+	OAuthCodeTTL              time.Duration
+	MagicLinkTTL              time.Duration
+	MagicLinkURL              string // frontend page the emailed link opens; token and email are appended
+	MagicLinkRateLimit        int    // links sent per address within MagicLinkRateWindow
+	MagicLinkRateWindow       time.Duration
+	EmailChangeTTL            time.Duration
+}
+
+type APIKeyConfig struct {
+	CacheTTL time.Duration // how long validated keys are cached in process
+
+	// DevBypass is a literal x-api-key value accepted without lookup.
+	// Empty disables it; it is refused when APP_ENV is production.
+	DevBypass string
+}
+
+// Client authentication schemes for a route group
+const (
+	ClientAuthAPIKey    = "api_key"   // x-api-key
+	ClientAuthSignature = "signature" // HMAC request signing
+	ClientAuthAny       = "any"       // either of the above
+)
+
+// ClientAuthConfig selects how callers of each route group identify
+// themselves and configures HMAC request signing.
+type ClientAuthConfig struct {
+	Public  string
+	Private string
+
+	SigningClients map[string]string // client ID -> shared secret
+	SigningMaxSkew time.Duration     // accepted distance between X-Timestamp and now
+	NonceCacheSize int
+}
+
+// OIDCProviderConfig is an external OpenID Connect identity provider users
+// may sign in with. Endpoints and keys are discovered from the issuer.
+type OIDCProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string   // our callback page registered with the provider
+	Scopes       []string // requested in addition to openid
+}
+
+type OIDCConfig struct {
+	Providers map[string]OIDCProviderConfig // keyed by the name used in the URL
+	StateTTL  time.Duration                 // how long a started login may take to come back
+}
+
+// Password hashers
+const (
+	PasswordHasherArgon2id = "argon2id"
+	PasswordHasherBcrypt   = "bcrypt"
+)
+
+// PasswordConfig selects the hasher for new passwords and the policy they
+// must meet. Stored hashes made with another hasher or other parameters
+// still verify and are replaced on the next successful login.
+type PasswordConfig struct {
+	Hasher        string
+	Argon2Memory  uint32 // KiB
+	Argon2Time    uint32 // passes over memory
+	Argon2Threads uint8
+	BcryptCost    int
+
+	MinLength     int // in characters
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	History       int    // most recent passwords, the current one included, that may not be reused; 0 disables
+	BreachedFile  string // SHA-1 breached-password list sorted by hash; empty disables the check
+}
+
+// AccountConfig controls what happens to deleted accounts
+type AccountConfig struct {
+	DeletedRetention time.Duration // how long deleted accounts can be restored before they are purged; 0 keeps them
+	PurgeInterval    time.Duration // how often the purge runs
+	PurgeBatchSize   int           // accounts purged per query
+
+	// StatusExpiryInterval is how often temporary bans and suspensions that
+	// have run out are reset to active
+	StatusExpiryInterval time.Duration
 }
 
 var cfg *Config
@@ -49,8 +189,9 @@ func Load() {
 
 	cfg = &Config{
 		App: AppConfig{
-			Env:  getEnv("APP_ENV", "development"),
-			Port: getEnv("APP_PORT", "8080"),
+			Env:            getEnv("APP_ENV", "development"),
+			Port:           getEnv("APP_PORT", "8080"),
+			TrustedProxies: getEnvList("TRUSTED_PROXIES"),
 		},
 		DB: DBConfig{
 			Host:            getEnv("DB_HOST", "localhost"),
@@ -64,9 +205,118 @@ func Load() {
 		},
 		JWT: JWTConfig{
 			Secret:            mustGetEnv("JWT_SECRET"),
+			Algorithm:         getEnv("JWT_ALGORITHM", "HS256"),
+			PrivateKeyFile:    getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			PublicKeyFiles:    getEnvList("JWT_PUBLIC_KEY_FILES"),
+			AcceptHS256:       getEnvBool("JWT_ACCEPT_HS256", false),
+			Issuer:            getEnv("JWT_ISSUER", ""),
+			Audience:          getEnv("JWT_AUDIENCE", ""),
+			Leeway:            getEnvDuration("JWT_LEEWAY", 30*time.Second),
 			AccessExpiration:  mustGetDuration("JWT_ACCESS_EXPIRES_IN"),
 			RefreshExpiration: getEnvDuration("JWT_REFRESH_EXPIRES_IN", 7*24*time.Hour), // Default 7 days
+			MFAExpiration:     getEnvDuration("JWT_MFA_EXPIRES_IN", 5*time.Minute),
+			ImpersonationTTL:  getEnvDuration("JWT_IMPERSONATION_EXPIRES_IN", 15*time.Minute),
+			RevocationStore:   getEnv("JWT_REVOCATION_STORE", "memory"),
+		},
+		Lockout: LockoutConfig{
+			Store:          getEnv("LOGIN_ATTEMPT_STORE", "memory"),
+			FreeAttempts:   getEnvInt("LOGIN_FREE_ATTEMPTS", 3),
+			MaxAttempts:    getEnvInt("LOGIN_MAX_ATTEMPTS", 10),
+			IPFreeAttempts: getEnvInt("LOGIN_IP_FREE_ATTEMPTS", 20),
+			IPMaxAttempts:  getEnvInt("LOGIN_IP_MAX_ATTEMPTS", 100),
+			BaseDelay:      getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+			MaxDelay:       getEnvDuration("LOGIN_BACKOFF_MAX", 15*time.Minute),
+			Window:         getEnvDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+			LockDuration:   getEnvDuration("LOGIN_LOCKOUT_DURATION", 30*time.Minute),
 		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "no-reply@localhost"),
+			FileDir:      getEnv("MAIL_FILE_DIR", "tmp/mail"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "1025"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Auth: AuthConfig{
+			PasswordResetTTL:          getEnvDuration("PASSWORD_RESET_TTL", 15*time.Minute),
+			PasswordResetRateLimit:    getEnvInt("PASSWORD_RESET_RATE_LIMIT", 3),
+			PasswordResetRateWindow:   getEnvDuration("PASSWORD_RESET_RATE_WINDOW", time.Hour),
+			EmailVerificationTTL:      getEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			EmailVerificationCooldown: getEnvDuration("EMAIL_VERIFICATION_COOLDOWN", time.Minute),
+			MFAIssuer:                 getEnv("MFA_ISSUER", "Golang API"),
+			OAuthCodeTTL:              getEnvDuration("OAUTH_CODE_TTL", time.Minute),
+			MagicLinkTTL:              getEnvDuration("MAGIC_LINK_TTL", 15*time.Minute),
+			MagicLinkURL:              getEnv("MAGIC_LINK_URL", "http://localhost:3000/login/magic"),
+			MagicLinkRateLimit:        getEnvInt("MAGIC_LINK_RATE_LIMIT", 3),
+			MagicLinkRateWindow:       getEnvDuration("MAGIC_LINK_RATE_WINDOW", time.Hour),
+			EmailChangeTTL:            getEnvDuration("EMAIL_CHANGE_TTL", time.Hour),
+		},
+		APIKey: APIKeyConfig{
+			CacheTTL:  getEnvDuration("API_KEY_CACHE_TTL", time.Minute),
+			DevBypass: getEnv("API_KEY_DEV_BYPASS", ""),
+		},
+		ClientAuth: ClientAuthConfig{
+			Public:         getEnvClientAuth("CLIENT_AUTH_PUBLIC"),
+			Private:        getEnvClientAuth("CLIENT_AUTH_PRIVATE"),
+			SigningClients: getEnvPairs("SIGNING_CLIENTS"),
+			SigningMaxSkew: getEnvDuration("SIGNING_MAX_SKEW", 5*time.Minute),
+			NonceCacheSize: getEnvInt("SIGNING_NONCE_CACHE_SIZE", 100000),
+		},
+		OIDC: OIDCConfig{
+			Providers: getOIDCProviders(),
+			StateTTL:  getEnvDuration("OIDC_STATE_TTL", 10*time.Minute),
+		},
+		Password: PasswordConfig{
+			Hasher:        getEnvPasswordHasher("PASSWORD_HASHER"),
+			Argon2Memory:  uint32(getEnvInt("PASSWORD_ARGON2_MEMORY", 64*1024)),
+			Argon2Time:    uint32(getEnvInt("PASSWORD_ARGON2_TIME", 3)),
+			Argon2Threads: uint8(getEnvInt("PASSWORD_ARGON2_THREADS", 4)),
+			BcryptCost:    getEnvInt("PASSWORD_BCRYPT_COST", bcrypt.DefaultCost),
+			MinLength:     getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:     getEnvInt("PASSWORD_MAX_LENGTH", 128),
+			RequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+			History:       getEnvInt("PASSWORD_HISTORY", 5),
+			BreachedFile:  getEnv("PASSWORD_BREACHED_FILE", ""),
+		},
+		Account: AccountConfig{
+			DeletedRetention: getEnvDuration("ACCOUNT_DELETED_RETENTION", 30*24*time.Hour),
+			PurgeInterval:    getEnvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+			PurgeBatchSize:   getEnvInt("ACCOUNT_PURGE_BATCH_SIZE", 100),
+
+			StatusExpiryInterval: getEnvDuration("ACCOUNT_STATUS_EXPIRY_INTERVAL", time.Minute),
+		},
+	}
+
+	if s := cfg.JWT.RevocationStore; s != "memory" && s != "mysql" {
+		log.Fatalf("JWT_REVOCATION_STORE must be memory or mysql, got %q", s)
+	}
+
+	if cfg.App.Env == "production" && cfg.APIKey.DevBypass != "" {
+		log.Fatal("API_KEY_DEV_BYPASS must not be set in production")
+	}
+
+	if cfg.ClientAuth.SigningMaxSkew <= 0 {
+		log.Fatal("SIGNING_MAX_SKEW must be positive")
+	}
+	if cfg.ClientAuth.NonceCacheSize < 1 {
+		log.Fatal("SIGNING_NONCE_CACHE_SIZE must be positive")
+	}
+
+	if p := cfg.Password; p.Argon2Memory < 8*uint32(p.Argon2Threads) || p.Argon2Time < 1 || p.Argon2Threads < 1 {
+		log.Fatal("invalid PASSWORD_ARGON2_* parameters")
+	}
+	if a := cfg.Account; a.DeletedRetention > 0 && (a.PurgeInterval <= 0 || a.PurgeBatchSize < 1) {
+		log.Fatal("ACCOUNT_PURGE_INTERVAL and ACCOUNT_PURGE_BATCH_SIZE must be positive")
+	}
+	if cfg.Account.StatusExpiryInterval <= 0 {
+		log.Fatal("ACCOUNT_STATUS_EXPIRY_INTERVAL must be positive")
+	}
+	if p := cfg.Password; p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+		log.Fatalf("PASSWORD_BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
 	}
 }
 
@@ -84,6 +334,84 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvList reads a comma-separated list, ignoring blank entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvPairs reads a comma-separated list of key:value pairs
+func getEnvPairs(key string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range getEnvList(key) {
+		k, v, ok := strings.Cut(item, ":")
+		if !ok || k == "" || v == "" {
+			log.Fatalf("invalid entry in %s, expected key:value", key)
+		}
+		pairs[k] = v
+	}
+	return pairs
+}
+
+// getOIDCProviders reads OIDC_PROVIDERS=name,... and the OIDC_<NAME>_*
+// settings of each listed provider
+func getOIDCProviders() map[string]OIDCProviderConfig {
+	providers := make(map[string]OIDCProviderConfig)
+	for _, name := range getEnvList("OIDC_PROVIDERS") {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		scopes := getEnvList(prefix + "SCOPES")
+		if len(scopes) == 0 {
+			scopes = []string{"email", "profile"}
+		}
+		providers[strings.ToLower(name)] = OIDCProviderConfig{
+			Issuer:       mustGetEnv(prefix + "ISSUER"),
+			ClientID:     mustGetEnv(prefix + "CLIENT_ID"),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  mustGetEnv(prefix + "REDIRECT_URL"),
+			Scopes:       scopes,
+		}
+	}
+	return providers
+}
+
+func getEnvPasswordHasher(key string) string {
+	v := getEnv(key, PasswordHasherArgon2id)
+	switch v {
+	case PasswordHasherArgon2id, PasswordHasherBcrypt:
+		return v
+	}
+	log.Fatalf("invalid %s %q, expected %s or %s", key, v, PasswordHasherArgon2id, PasswordHasherBcrypt)
+	return ""
+}
+
+func getEnvClientAuth(key string) string {
+	v := getEnv(key, ClientAuthAPIKey)
+	switch v {
+	case ClientAuthAPIKey, ClientAuthSignature, ClientAuthAny:
+		return v
+	}
+	log.Fatalf("invalid %s %q, expected %s, %s or %s", key, v, ClientAuthAPIKey, ClientAuthSignature, ClientAuthAny)
+	return ""
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("invalid bool for %s, using fallback: %v", key, fallback)
+		return fallback
+	}
+	return b
+}
+
 func getEnvInt(key string, fallback int) int {
 	v := os.Getenv(key)
 	if v == "" {
@@ -0,0 +1,7 @@
+package constants
+
+// Audit log actions (audit_logs.action)
+const (
+	AuditActionImpersonate = "user.impersonate"
+	AuditActionRestore     = "user.restore"
+)
@@ -5,4 +5,8 @@ type CtxKey string
 const (
 	UserContextKey      CtxKey = "user_claims"
 	RequestIDContextKey CtxKey = "request_id"
+	APIKeyContextKey    CtxKey = "api_key"
+	ActorContextKey     CtxKey = "actor" // admin behind an impersonation token
+
+	SigningClientContextKey CtxKey = "signing_client"
 )
@@ -0,0 +1,35 @@
+package constants
+
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+
+	// TokenTypeMFAPending is issued after the password step of a 2FA login and
+	// is only accepted by /auth/2fa/verify
+	TokenTypeMFAPending = "mfa_pending"
+)
+
+// One-time token purposes (user_tokens.purpose)
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMagicLink         = "magic_link"   // target holds the email the link was sent to
+	TokenPurposeEmailChange       = "email_change" // target holds the new address
+)
+
+// OAuth2 grant types
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// OAuth2 scopes that clients may be granted
+const (
+	ScopeProfile       = "profile"
+	ScopeEmail         = "email"
+	ScopeOfflineAccess = "offline_access" // required for a refresh token
+)
+
+// MaxOTPAttempts is how many wrong codes are accepted before a one-time token is burned
+const MaxOTPAttempts = 5
@@ -1,10 +1,11 @@
 package constants
 
 const (
-	UserStatusInactive = 0
-	UserStatusActive   = 1
-	UserStatusPending  = 2
-	UserStatusBanned   = 3
+	UserStatusInactive  = 0
+	UserStatusActive    = 1
+	UserStatusPending   = 2
+	UserStatusBanned    = 3
+	UserStatusSuspended = 4 // temporarily blocked, usually with an expiry
 )
 
 const (
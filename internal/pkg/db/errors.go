@@ -0,0 +1,16 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ER_DUP_ENTRY
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateKey reports whether err is a unique key violation
+func IsDuplicateKey(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == mysqlDuplicateEntry
+}
@@ -85,3 +85,12 @@ func InsertTx(ctx context.Context, tx *sql.Tx, query string, args ...any) (int64
 	}
 	return result.LastInsertId()
 }
+
+// ExecTx is Exec but uses an existing transaction
+func ExecTx(ctx context.Context, tx *sql.Tx, query string, args ...any) (int64, error) {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// EscapeLike escapes the LIKE wildcards in s, so user input only ever
+// matches literally (MySQL's default escape character is the backslash)
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// FulltextPrefixTerms turns free text into a MATCH ... AGAINST search in
+// BOOLEAN MODE where every word must occur as a word prefix, e.g.
+// "john exa" becomes "+john* +exa*". Operators in the input are dropped so
+// they cannot change the meaning of the search. It returns "" when no word
+// is left.
+func FulltextPrefixTerms(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		// The built-in parser treats "_" as part of a word
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for i, w := range words {
+		words[i] = "+" + w + "*"
+	}
+	return strings.Join(words, " ")
+}
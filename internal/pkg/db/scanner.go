@@ -6,30 +6,23 @@ import (
 	"reflect"
 )
 
-// scanPlan pre-calculates the mapping between SQL columns and struct fields
+// scanPlan pre-calculates the mapping between SQL columns and struct fields.
+// Each entry is a field index path (see reflect.Value.FieldByIndex) so that
+// fields of embedded structs can be targeted too.
 type scanPlan struct {
-	fieldIndices []int
+	fieldIndices [][]int
 }
 
 // buildPlan creates a scanPlan for a given type and set of SQL columns
 func buildPlan(t reflect.Type, columns []string) *scanPlan {
+	// nil index = skip column
 	plan := &scanPlan{
-		fieldIndices: make([]int, len(columns)),
-	}
-
-	// Default to -1 (skip column)
-	for i := range plan.fieldIndices {
-		plan.fieldIndices[i] = -1
+		fieldIndices: make([][]int, len(columns)),
 	}
 
 	// Create a temporary map of db tags to field indices
-	tagToIndex := make(map[string]int)
-	for i := 0; i < t.NumField(); i++ {
-		tag := t.Field(i).Tag.Get("db")
-		if tag != "" {
-			tagToIndex[tag] = i
-		}
-	}
+	tagToIndex := make(map[string][]int)
+	collectTags(t, nil, tagToIndex)
 
 	// Map columns to indices
 	for i, col := range columns {
@@ -41,6 +34,30 @@ func buildPlan(t reflect.Type, columns []string) *scanPlan {
 	return plan
 }
 
+// collectTags walks t (and any embedded structs) recording the index path of
+// every db-tagged field. Outer fields win over embedded ones with the same tag.
+func collectTags(t reflect.Type, prefix []int, tagToIndex map[string][]int) {
+	var embedded []int
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("db")
+		if tag != "" {
+			if _, exists := tagToIndex[tag]; !exists {
+				tagToIndex[tag] = append(append([]int{}, prefix...), i)
+			}
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, i)
+		}
+	}
+
+	for _, i := range embedded {
+		collectTags(t.Field(i).Type, append(append([]int{}, prefix...), i), tagToIndex)
+	}
+}
+
 // Scan scans multiple rows into a slice of structs or a single struct
 func Scan(rows *sql.Rows, dst any) error {
 	v := reflect.ValueOf(dst)
@@ -97,10 +114,10 @@ func scanSlice(rows *sql.Rows, slice reflect.Value, columns []string) error {
 
 func scanItem(rows *sql.Rows, v reflect.Value, plan *scanPlan) error {
 	pointers := make([]any, len(plan.fieldIndices))
-	
+
 	for i, fieldIdx := range plan.fieldIndices {
-		if fieldIdx != -1 {
-			pointers[i] = v.Field(fieldIdx).Addr().Interface()
+		if fieldIdx != nil {
+			pointers[i] = v.FieldByIndex(fieldIdx).Addr().Interface()
 		} else {
 			var skip any
 			pointers[i] = &skip
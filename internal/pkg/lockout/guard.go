@@ -0,0 +1,163 @@
+package lockout
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// Policy describes how failures of one key are throttled.
+//
+// The first FreeAttempts failures are not delayed. After that every further
+// failure doubles the wait, starting at BaseDelay and capped at MaxDelay.
+// Once MaxAttempts is reached the key is blocked until Window has passed
+// since the last failure.
+type Policy struct {
+	FreeAttempts int
+	MaxAttempts  int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	Window       time.Duration
+}
+
+// RetryAfter returns how long the caller has to wait before the next attempt
+func (p Policy) RetryAfter(a Attempts, now time.Time) time.Duration {
+	if a.Failures < p.FreeAttempts {
+		return 0
+	}
+
+	var delay time.Duration
+	if p.MaxAttempts > 0 && a.Failures >= p.MaxAttempts {
+		delay = p.Window
+	} else {
+		shift := a.Failures - p.FreeAttempts
+		delay = p.MaxDelay
+		if shift < 32 {
+			if d := p.BaseDelay << shift; d > 0 && d < p.MaxDelay {
+				delay = d
+			}
+		}
+	}
+
+	wait := a.LastFailure.Add(delay).Sub(now)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// Guard applies per-account and per-IP policies on top of a Store
+type Guard struct {
+	store        Store
+	account      Policy
+	ip           Policy
+	lockDuration time.Duration
+}
+
+func NewGuard(store Store, cfg config.LockoutConfig) *Guard {
+	return &Guard{
+		store: store,
+		account: Policy{
+			FreeAttempts: cfg.FreeAttempts,
+			MaxAttempts:  cfg.MaxAttempts,
+			BaseDelay:    cfg.BaseDelay,
+			MaxDelay:     cfg.MaxDelay,
+			Window:       cfg.Window,
+		},
+		ip: Policy{
+			FreeAttempts: cfg.IPFreeAttempts,
+			MaxAttempts:  cfg.IPMaxAttempts,
+			BaseDelay:    cfg.BaseDelay,
+			MaxDelay:     cfg.MaxDelay,
+			Window:       cfg.Window,
+		},
+		lockDuration: cfg.LockDuration,
+	}
+}
+
+// LockDuration is how long an account stays locked after MaxAttempts failures
+func (g *Guard) LockDuration() time.Duration {
+	return g.lockDuration
+}
+
+// Check returns the remaining backoff for this account/IP pair, 0 if an attempt is allowed now
+func (g *Guard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	acct, err := g.store.Get(ctx, accountKey(email), g.account.Window)
+	if err != nil {
+		return 0, err
+	}
+	byIP, err := g.store.Get(ctx, ipKey(ip), g.ip.Window)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(g.account.RetryAfter(acct, now), g.ip.RetryAfter(byIP, now)), nil
+}
+
+// Fail records a failed attempt. It reports lock=true when the account has
+// reached MaxAttempts and should be put into the locked state; the account
+// counter is reset at that point so backoff starts over once the lock ends.
+func (g *Guard) Fail(ctx context.Context, email, ip string) (lock bool, err error) {
+	if _, err := g.store.RegisterFailure(ctx, ipKey(ip), g.ip.Window); err != nil {
+		return false, err
+	}
+
+	acct, err := g.store.RegisterFailure(ctx, accountKey(email), g.account.Window)
+	if err != nil {
+		return false, err
+	}
+
+	if g.account.MaxAttempts > 0 && acct.Failures >= g.account.MaxAttempts {
+		return true, g.store.Reset(ctx, accountKey(email))
+	}
+	return false, nil
+}
+
+// Succeed clears the account counter. The IP counter is left alone so an
+// attacker cannot reset it by logging into their own account in between.
+func (g *Guard) Succeed(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
+
+// Unlock clears the account counter (used by the admin unlock endpoint)
+func (g *Guard) Unlock(ctx context.Context, email string) error {
+	return g.store.Reset(ctx, accountKey(email))
+}
+
+func accountKey(email string) string {
+	return "acct:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+var (
+	defaultGuard *Guard
+	defaultMu    sync.Mutex
+)
+
+// Default returns the guard used by the login flow. Unless SetDefault was
+// called it is an in-memory guard configured from the environment.
+func Default() *Guard {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultGuard == nil {
+		cfg := config.Get().Lockout
+		defaultGuard = NewGuard(NewMemoryStore(time.Minute, cfg.Window), cfg)
+	}
+	return defaultGuard
+}
+
+// SetDefault replaces the guard used by the login flow
+func SetDefault(g *Guard) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultGuard = g
+}
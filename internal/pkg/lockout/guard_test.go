@@ -0,0 +1,115 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+func TestPolicyRetryAfter(t *testing.T) {
+	p := Policy{
+		FreeAttempts: 3,
+		MaxAttempts:  10,
+		BaseDelay:    time.Second,
+		MaxDelay:     30 * time.Second,
+		Window:       15 * time.Minute,
+	}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int
+		ago      time.Duration // since the last failure
+		want     time.Duration
+	}{
+		{name: "no failures", policy: p, failures: 0, want: 0},
+		{name: "last free attempt", policy: p, failures: 2, want: 0},
+		{name: "first delayed attempt", policy: p, failures: 3, want: time.Second},
+		{name: "delay doubles", policy: p, failures: 4, want: 2 * time.Second},
+		{name: "delay doubles again", policy: p, failures: 6, want: 8 * time.Second},
+		{name: "capped at MaxDelay", policy: p, failures: 8, want: 30 * time.Second},
+		{name: "one below MaxAttempts", policy: p, failures: 9, want: 30 * time.Second},
+		{name: "blocked for the window", policy: p, failures: 10, want: 15 * time.Minute},
+		{name: "still blocked later", policy: p, failures: 12, ago: 5 * time.Minute, want: 10 * time.Minute},
+		{name: "part of the delay passed", policy: p, failures: 5, ago: time.Second, want: 3 * time.Second},
+		{name: "delay passed", policy: p, failures: 5, ago: time.Minute, want: 0},
+		{name: "window passed", policy: p, failures: 10, ago: time.Hour, want: 0},
+		{name: "huge failure count does not overflow", policy: Policy{FreeAttempts: 0, BaseDelay: time.Second, MaxDelay: time.Minute}, failures: 1000, want: time.Minute},
+		{name: "shift overflowing to negative", policy: Policy{FreeAttempts: 0, BaseDelay: time.Hour, MaxDelay: 2 * time.Hour}, failures: 31, want: 2 * time.Hour},
+		{name: "no MaxAttempts never blocks", policy: Policy{FreeAttempts: 1, BaseDelay: time.Second, MaxDelay: time.Minute, Window: time.Hour}, failures: 50, want: time.Minute},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := Attempts{Failures: tc.failures, LastFailure: now.Add(-tc.ago)}
+			if got := tc.policy.RetryAfter(a, now); got != tc.want {
+				t.Errorf("RetryAfter = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	ctx := context.Background()
+	g := NewGuard(NewMemoryStore(time.Hour, time.Hour), config.LockoutConfig{
+		FreeAttempts:   2,
+		MaxAttempts:    4,
+		IPFreeAttempts: 100,
+		IPMaxAttempts:  200,
+		BaseDelay:      time.Minute,
+		MaxDelay:       time.Hour,
+		Window:         time.Hour,
+		LockDuration:   time.Hour,
+	})
+
+	fail := func(email string) bool {
+		t.Helper()
+		lock, err := g.Fail(ctx, email, "203.0.113.7")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lock
+	}
+	wait := func(email string) time.Duration {
+		t.Helper()
+		d, err := g.Check(ctx, email, "203.0.113.7")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	for i := range 2 {
+		if fail("Alice@Example.com") {
+			t.Fatalf("locked after %d failures", i+1)
+		}
+	}
+	// The key ignores case and surrounding space
+	if d := wait(" alice@example.com "); d <= 0 || d > time.Minute {
+		t.Fatalf("wait after the free attempts = %s, want up to a minute", d)
+	}
+	if d := wait("bob@example.com"); d != 0 {
+		t.Fatalf("other account waits %s", d)
+	}
+
+	fail("alice@example.com")
+	if !fail("alice@example.com") {
+		t.Fatal("not locked at MaxAttempts")
+	}
+	// The account counter starts over once the lock is applied
+	if d := wait("alice@example.com"); d != 0 {
+		t.Fatalf("wait after lock = %s, want 0", d)
+	}
+
+	fail("alice@example.com")
+	fail("alice@example.com")
+	if err := g.Succeed(ctx, "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if d := wait("alice@example.com"); d != 0 {
+		t.Fatalf("wait after success = %s, want 0", d)
+	}
+}
@@ -0,0 +1,68 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process memory (single instance deployments)
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempts
+}
+
+func NewMemoryStore(cleanupInterval, window time.Duration) *MemoryStore {
+	s := &MemoryStore{attempts: make(map[string]Attempts)}
+	go s.janitor(cleanupInterval, window)
+	return s
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string, window time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a := s.attempts[key]
+	if time.Since(a.LastFailure) > window {
+		return Attempts{}, nil
+	}
+	return a, nil
+}
+
+func (s *MemoryStore) RegisterFailure(_ context.Context, key string, window time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	a := s.attempts[key]
+	if now.Sub(a.LastFailure) > window {
+		a = Attempts{}
+	}
+	a.Failures++
+	a.LastFailure = now
+	s.attempts[key] = a
+
+	return a, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.attempts, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) janitor(interval, window time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for key, a := range s.attempts {
+			if time.Since(a.LastFailure) > window {
+				delete(s.attempts, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
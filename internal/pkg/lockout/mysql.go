@@ -0,0 +1,70 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// MySQLStore shares counters between instances through the login_attempts table
+type MySQLStore struct {
+	db *sql.DB
+}
+
+func NewMySQLStore(db *sql.DB, purgeInterval, window time.Duration) *MySQLStore {
+	s := &MySQLStore{db: db}
+	go s.purge(purgeInterval, window)
+	return s
+}
+
+func (s *MySQLStore) Get(ctx context.Context, key string, window time.Duration) (Attempts, error) {
+	var a Attempts
+	err := s.db.QueryRowContext(ctx, `
+		SELECT failures, last_failure FROM login_attempts
+		WHERE attempt_key = ? AND last_failure > NOW(3) - INTERVAL ? SECOND
+	`, key, int64(window.Seconds())).Scan(&a.Failures, &a.LastFailure)
+
+	if err == sql.ErrNoRows {
+		return Attempts{}, nil
+	}
+	return a, err
+}
+
+func (s *MySQLStore) RegisterFailure(ctx context.Context, key string, window time.Duration) (Attempts, error) {
+	// failures is assigned before last_failure, so the IF still sees the previous failure time
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO login_attempts (attempt_key, failures, last_failure)
+		VALUES (?, 1, NOW(3))
+		ON DUPLICATE KEY UPDATE
+			failures = IF(last_failure <= NOW(3) - INTERVAL ? SECOND, 1, failures + 1),
+			last_failure = NOW(3)
+	`, key, int64(window.Seconds()))
+	if err != nil {
+		return Attempts{}, err
+	}
+
+	return s.Get(ctx, key, window)
+}
+
+func (s *MySQLStore) Reset(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE attempt_key = ?", key)
+	return err
+}
+
+func (s *MySQLStore) purge(interval, window time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		_, err := s.db.ExecContext(ctx,
+			"DELETE FROM login_attempts WHERE last_failure <= NOW(3) - INTERVAL ? SECOND",
+			int64(window.Seconds()),
+		)
+		if err != nil {
+			slog.Error("login_attempts_purge_failed", "error", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,20 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Attempts is the failure state tracked for one key (an account or an IP)
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// Store persists failed-attempt counters. Counters whose last failure is older
+// than window are treated as empty.
+type Store interface {
+	Get(ctx context.Context, key string, window time.Duration) (Attempts, error)
+	RegisterFailure(ctx context.Context, key string, window time.Duration) (Attempts, error)
+	Reset(ctx context.Context, key string) error
+}
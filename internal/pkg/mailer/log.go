@@ -0,0 +1,23 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogMailer writes emails to the application log. For local development only:
+// message bodies (including codes) end up in the logs.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	slog.Info("mail_sent",
+		"to", msg.To,
+		"subject", msg.Subject,
+		"body", msg.Body,
+	)
+	return nil
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional emails (OTP codes, links, notifications)
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New builds the mailer selected by MAIL_DRIVER
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "file":
+		return NewFileMailer(cfg.FileDir), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
+
+var (
+	defaultMailer Mailer = NewLogMailer()
+	defaultMu     sync.RWMutex
+)
+
+// Default returns the mailer used by the services
+func Default() Mailer {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultMailer
+}
+
+// SetDefault replaces the mailer used by the services
+func SetDefault(m Mailer) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMailer = m
+}
@@ -0,0 +1,319 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// delivery is what the fake server received for one message
+type delivery struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP is a minimal SMTP server that speaks just enough of the protocol
+// for net/smtp.SendMail: EHLO, optional STARTTLS and AUTH PLAIN, MAIL, RCPT,
+// DATA and QUIT.
+type fakeSMTP struct {
+	ln       net.Listener
+	auth     bool // advertise AUTH PLAIN and accept username/password
+	username string
+	password string
+	cert     *tls.Certificate // advertise STARTTLS with this certificate
+	silent   bool             // accept connections but never greet
+
+	got chan delivery
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func startFakeSMTP(t *testing.T, s *fakeSMTP) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s.ln = ln
+	s.got = make(chan delivery, 1)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, c := range s.conns {
+			_ = c.Close()
+		}
+	})
+
+	return s
+}
+
+func (s *fakeSMTP) port() string {
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return port
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	if s.silent {
+		_, _ = io.Copy(io.Discard, conn)
+		return
+	}
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 fake ESMTP")
+
+	var d delivery
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			lines := []string{"fake"}
+			if s.cert != nil {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.auth {
+				lines = append(lines, "AUTH PLAIN")
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				_ = tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready to start TLS")
+			tc := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{*s.cert}})
+			if err := tc.Handshake(); err != nil {
+				return
+			}
+			conn = tc
+			tp = textproto.NewConn(tc)
+		case "AUTH":
+			mech, resp, _ := strings.Cut(arg, " ")
+			raw, _ := base64.StdEncoding.DecodeString(resp)
+			if mech == "PLAIN" && string(raw) == "\x00"+s.username+"\x00"+s.password {
+				_ = tp.PrintfLine("235 2.7.0 Authentication successful")
+			} else {
+				_ = tp.PrintfLine("535 5.7.8 Authentication failed")
+			}
+		case "MAIL":
+			d.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			_ = tp.PrintfLine("250 OK")
+		case "RCPT":
+			d.to = append(d.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 end with <CRLF>.<CRLF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			d.data = string(data)
+			s.got <- d
+			_ = tp.PrintfLine("250 OK queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 command not implemented")
+		}
+	}
+}
+
+// selfSignedCert returns a certificate for 127.0.0.1 that no client trusts
+func selfSignedCert(t *testing.T) *tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	srv := startFakeSMTP(t, &fakeSMTP{})
+	m := NewSMTPMailer("127.0.0.1", srv.port(), "", "", "noreply@example.com")
+
+	err := m.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Héllo there",
+		Body:    "Line one\nLine two\n",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var d delivery
+	select {
+	case d = <-srv.got:
+	default:
+		t.Fatal("no message delivered")
+	}
+
+	if d.from != "noreply@example.com" {
+		t.Errorf("MAIL FROM = %q, want noreply@example.com", d.from)
+	}
+	if len(d.to) != 1 || d.to[0] != "alice@example.com" {
+		t.Errorf("RCPT TO = %q, want [alice@example.com]", d.to)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(d.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+
+	headers := map[string]string{
+		"From":         "noreply@example.com",
+		"To":           "alice@example.com",
+		"Mime-Version": "1.0",
+		"Content-Type": "text/plain; charset=utf-8",
+	}
+	for k, want := range headers {
+		if got := msg.Header.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Héllo there" {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, "Héllo there")
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date header: %v", err)
+	}
+
+	body, _ := io.ReadAll(msg.Body)
+	if string(body) != "Line one\nLine two\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	raw := buildMessage("", Message{To: "bob@example.com", Subject: "Hi", Body: "a\nb"})
+
+	if bytes.Contains(raw, []byte("From:")) {
+		t.Error("From header written without a sender")
+	}
+	if !bytes.HasSuffix(raw, []byte("\r\n\r\na\r\nb")) {
+		t.Errorf("body not separated and CRLF terminated: %q", raw)
+	}
+}
+
+func TestSMTPMailerAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		auth      bool
+		password  string
+		wantErr   string
+		delivered bool
+	}{
+		{name: "valid credentials", host: "127.0.0.1", auth: true, password: "secret", delivered: true},
+		{name: "wrong password", host: "127.0.0.1", auth: true, password: "wrong", wantErr: "Authentication failed"},
+		{name: "server without AUTH", host: "127.0.0.1", password: "secret", wantErr: "doesn't support AUTH"},
+		{name: "host mismatch", host: "mail.example.com", auth: true, password: "secret", wantErr: "wrong host name"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := startFakeSMTP(t, &fakeSMTP{auth: tc.auth, username: "app", password: "secret"})
+
+			m := NewSMTPMailer(tc.host, srv.port(), "app", tc.password, "noreply@example.com")
+			m.addr = srv.ln.Addr().String()
+
+			err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hi", Body: "x"})
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("Send: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Fatalf("Send error = %v, want %q", err, tc.wantErr)
+			}
+
+			if got := len(srv.got) == 1; got != tc.delivered {
+				t.Errorf("delivered = %v, want %v", got, tc.delivered)
+			}
+		})
+	}
+}
+
+func TestSMTPMailerUntrustedStartTLS(t *testing.T) {
+	srv := startFakeSMTP(t, &fakeSMTP{cert: selfSignedCert(t)})
+	m := NewSMTPMailer("127.0.0.1", srv.port(), "", "", "noreply@example.com")
+
+	err := m.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hi", Body: "x"})
+
+	var verr *tls.CertificateVerificationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Send error = %v, want a certificate verification error", err)
+	}
+	if len(srv.got) != 0 {
+		t.Error("message delivered over an untrusted connection")
+	}
+}
+
+func TestSMTPMailerContextCancelled(t *testing.T) {
+	srv := startFakeSMTP(t, &fakeSMTP{silent: true})
+	m := NewSMTPMailer("127.0.0.1", srv.port(), "", "", "noreply@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := m.Send(ctx, Message{To: "alice@example.com", Subject: "Hi", Body: "x"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send error = %v, want context.DeadlineExceeded", err)
+	}
+}
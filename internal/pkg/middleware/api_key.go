@@ -1,25 +1,27 @@
 package middleware
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
 	"net/http"
-	"strings"
-	"time"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apikey"
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
-	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
 )
 
-type TokenPayload struct {
-	DateTime string `json:"date_time"`
-}
-
 func APIKey(next http.Handler) http.Handler {
+	// Config refuses to load a bypass key in production
+	bypass := config.Get().APIKey.DevBypass
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		// 1️⃣ Bypass routes
-		if strings.Contains(r.URL.Path, "webhook") ||
-			strings.Contains(r.URL.Path, "swagger") {
+		if isClientAuthExempt(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -33,47 +35,55 @@ func APIKey(next http.Handler) http.Handler {
 			return
 		}
 
-		// 3️⃣ Dev bypass
-		if key == "!!Devkrest!!" {
+		// 3️⃣ Dev bypass (only when API_KEY_DEV_BYPASS is set)
+		if bypass != "" && subtle.ConstantTimeCompare([]byte(key), []byte(bypass)) == 1 {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// 4️⃣ Decrypt key
-		decrypted, err := utils.DecryptKey(key)
+		// 4️⃣ Validate against the api_keys store
+		k, err := apikey.Default().Validate(r.Context(), key)
 		if err != nil {
+			var appErr *apperr.AppError
+			if !errors.As(err, &appErr) {
+				slog.Error("api_key_lookup_failed", "error", err)
+				response.InternalError(response.SendParams{W: w})
+				return
+			}
 			response.UnauthorizedAccess(response.SendParams{
-				W: w,
+				W:       w,
+				Message: appErr.Message,
+				Data:    appErr,
 			})
 			return
 		}
 
-		// 5️⃣ Parse JSON
-		var payload TokenPayload
-		if err := json.Unmarshal([]byte(decrypted), &payload); err != nil {
-			response.UnauthorizedAccess(response.SendParams{
-				W: w,
-			})
-			return
-		}
+		// ✅ Success
+		ctx := context.WithValue(r.Context(), constants.APIKeyContextKey, k)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		// 6️⃣ Validate time
-		tokenTime, err := time.Parse(time.RFC3339, payload.DateTime)
-		if err != nil {
-			response.UnauthorizedAccess(response.SendParams{
-				W: w,
-			})
-			return
-		}
+// APIKeyFromContext returns the key stored by the APIKey middleware. It is
+// absent for bypassed routes and the dev bypass key.
+func APIKeyFromContext(ctx context.Context) (*apikey.Key, bool) {
+	k, ok := ctx.Value(constants.APIKeyContextKey).(*apikey.Key)
+	return k, ok
+}
 
-		if time.Since(tokenTime) > time.Minute {
-			response.UnauthorizedAccess(response.SendParams{
-				W: w,
-			})
-			return
-		}
+// RequireAPIKeyScope lets a request through only if the API key it was
+// authenticated with grants scope. Requests that did not use an API key
+// (signed requests, the dev bypass, exempt routes) are not affected.
+// It must run after APIKey.
+func RequireAPIKeyScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if k, ok := APIKeyFromContext(r.Context()); ok && !k.HasScope(scope) {
+				forbidden(w, apperr.ErrAPIKeyScope)
+				return
+			}
 
-		// ✅ Success
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apikey"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+)
+
+func TestRequireAPIKeyScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    *apikey.Key // nil when the request was not authenticated with a key
+		scope  string
+		status int
+	}{
+		{name: "granted", key: &apikey.Key{Scopes: []string{apikey.ScopeUser}}, scope: apikey.ScopeUser, status: http.StatusOK},
+		{name: "wildcard", key: &apikey.Key{Scopes: []string{apikey.ScopeAll}}, scope: apikey.ScopeAdmin, status: http.StatusOK},
+		{name: "other group", key: &apikey.Key{Scopes: []string{apikey.ScopeUser, apikey.ScopeAuth}}, scope: apikey.ScopeAdmin, status: http.StatusForbidden},
+		{name: "no scopes", key: &apikey.Key{}, scope: apikey.ScopeAuth, status: http.StatusForbidden},
+		{name: "no api key", scope: apikey.ScopeAdmin, status: http.StatusOK},
+	}
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.key != nil {
+				r = r.WithContext(context.WithValue(r.Context(), constants.APIKeyContextKey, tc.key))
+			}
+			w := httptest.NewRecorder()
+
+			RequireAPIKeyScope(tc.scope)(ok).ServeHTTP(w, r)
+
+			if w.Code != tc.status {
+				t.Errorf("status = %d, want %d", w.Code, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/signing"
+)
+
+// ClientAuth returns the client authentication middleware for a route group.
+// With ClientAuthAny a request carrying X-Signature is verified as signed and
+// anything else must present an API key.
+func ClientAuth(scheme string) func(http.Handler) http.Handler {
+	switch scheme {
+	case config.ClientAuthSignature:
+		return Signature
+	case config.ClientAuthAny:
+		return func(next http.Handler) http.Handler {
+			signed, keyed := Signature(next), APIKey(next)
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(signing.HeaderSignature) != "" {
+					signed.ServeHTTP(w, r)
+					return
+				}
+				keyed.ServeHTTP(w, r)
+			})
+		}
+	default:
+		return APIKey
+	}
+}
+
+// isClientAuthExempt reports routes that skip client authentication entirely
+func isClientAuthExempt(r *http.Request) bool {
+	return strings.Contains(r.URL.Path, "webhook") ||
+		strings.Contains(r.URL.Path, "swagger")
+}
@@ -2,40 +2,184 @@ package middleware
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
 	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/revocation"
 	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
 )
 
+// JWT accepts first-party access tokens only. OAuth (delegated) tokens are
+// refused here so partner apps never reach routes that did not opt in via
+// OAuthBearer.
 func JWT(next http.Handler) http.Handler {
 	jwtService := utils.NewJWT()
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		auth := r.Header.Get("Authorization")
-		if !strings.HasPrefix(auth, "Bearer ") {
-			response.UnauthorizedAccess(response.SendParams{
-				W:       w,
-				Message: "missing token",
-			})
+		claims, ok := authenticate(w, r, jwtService)
+		if !ok {
 			return
 		}
 
-		token := strings.TrimPrefix(auth, "Bearer ")
-
-		claims, err := jwtService.Parse(token)
-		if err != nil {
+		if claims.ClientID != "" {
 			response.UnauthorizedAccess(response.SendParams{
 				W:       w,
-				Message: "invalid token",
+				Message: apperr.ErrInvalidTokenType.Message,
+				Data:    apperr.ErrInvalidTokenType,
 			})
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), constants.UserContextKey, claims)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, withClaims(w, r, claims))
 	})
 }
+
+// OAuthBearer accepts first-party access tokens and delegated tokens of users
+// that were granted every one of scopes. Client-only tokens (client_credentials)
+// carry no user and are refused.
+func OAuthBearer(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		jwtService := utils.NewJWT()
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			claims, ok := authenticate(w, r, jwtService)
+			if !ok {
+				return
+			}
+
+			if claims.UserID == 0 {
+				response.UnauthorizedAccess(response.SendParams{
+					W:       w,
+					Message: apperr.ErrInvalidTokenType.Message,
+					Data:    apperr.ErrInvalidTokenType,
+				})
+				return
+			}
+
+			if claims.ClientID != "" {
+				granted := strings.Fields(claims.Scope)
+				for _, scope := range scopes {
+					if !slices.Contains(granted, scope) {
+						forbidden(w, apperr.ErrInsufficientScope)
+						return
+					}
+				}
+			}
+
+			next.ServeHTTP(w, withClaims(w, r, claims))
+		})
+	}
+}
+
+// authenticate parses the bearer token and makes sure it is a live access
+// token. On failure it has already written the response.
+func authenticate(w http.ResponseWriter, r *http.Request, jwtService *utils.Service) (*utils.Claims, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		response.UnauthorizedAccess(response.SendParams{
+			W:       w,
+			Message: "missing token",
+		})
+		return nil, false
+	}
+
+	token := strings.TrimPrefix(auth, "Bearer ")
+
+	claims, err := jwtService.Parse(token)
+	if err != nil {
+		response.UnauthorizedAccess(response.SendParams{
+			W:       w,
+			Message: "invalid token",
+		})
+		return nil, false
+	}
+
+	// Refresh and 2FA-pending tokens must never grant access
+	if claims.Type != constants.TokenTypeAccess {
+		response.UnauthorizedAccess(response.SendParams{
+			W:       w,
+			Message: apperr.ErrInvalidTokenType.Message,
+			Data:    apperr.ErrInvalidTokenType,
+		})
+		return nil, false
+	}
+
+	revoked, err := IsTokenRevoked(r.Context(), claims)
+	if err != nil {
+		slog.Error("revocation_check_failed", "error", err)
+		response.InternalError(response.SendParams{W: w})
+		return nil, false
+	}
+	if revoked {
+		response.UnauthorizedAccess(response.SendParams{
+			W:       w,
+			Message: apperr.ErrTokenRevoked.Message,
+			Data:    apperr.ErrTokenRevoked,
+		})
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// HeaderImpersonatedBy is set on every response to an impersonation token,
+// carrying the UUID of the admin behind it
+const HeaderImpersonatedBy = "X-Impersonated-By"
+
+// withClaims stores the authenticated claims in the request context. For
+// impersonation tokens the actor is stored too and the response is flagged.
+func withClaims(w http.ResponseWriter, r *http.Request, claims *utils.Claims) *http.Request {
+	ctx := context.WithValue(r.Context(), constants.UserContextKey, claims)
+
+	if claims.Actor != nil {
+		ctx = context.WithValue(ctx, constants.ActorContextKey, claims.Actor)
+		w.Header().Set(HeaderImpersonatedBy, claims.Actor.Subject)
+	}
+
+	return r.WithContext(ctx)
+}
+
+// ActorFromContext returns the admin behind an impersonation token. ok is
+// false for requests made by the user themselves.
+func ActorFromContext(ctx context.Context) (*utils.Actor, bool) {
+	actor, ok := ctx.Value(constants.ActorContextKey).(*utils.Actor)
+	return actor, ok
+}
+
+// ClaimsFromContext returns the claims stored by the JWT middleware
+func ClaimsFromContext(ctx context.Context) (*utils.Claims, bool) {
+	claims, ok := ctx.Value(constants.UserContextKey).(*utils.Claims)
+	return claims, ok
+}
+
+// IsTokenRevoked checks the token's jti, its session and, for user tokens,
+// the user's "logout everywhere" cutoff
+func IsTokenRevoked(ctx context.Context, claims *utils.Claims) (bool, error) {
+	store := revocation.Default()
+
+	if claims.ID != "" {
+		revoked, err := store.IsRevoked(ctx, claims.ID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	if claims.SessionID != "" {
+		revoked, err := store.IsSessionRevoked(ctx, claims.SessionID)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	if claims.IssuedAt == nil || claims.UserID == 0 {
+		return false, nil
+	}
+	return store.IsUserRevoked(ctx, claims.UserID, claims.IssuedAt.Time)
+}
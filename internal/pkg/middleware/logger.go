@@ -20,6 +20,18 @@ func Logger(next http.Handler) http.Handler {
 			"remote", r.RemoteAddr,
 		)
 
+		// Actions taken while impersonating are attributed to the admin
+		if actor, ok := ActorFromContext(r.Context()); ok {
+			claims, _ := ClaimsFromContext(r.Context())
+			slog.Info("impersonated_request",
+				"request_id", requestID,
+				"actor", actor.Subject,
+				"user", claims.UUID,
+				"method", r.Method,
+				"path", r.URL.Path,
+			)
+		}
+
 		next.ServeHTTP(w, r)
 
 		slog.Debug("request_completed",
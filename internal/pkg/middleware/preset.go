@@ -31,9 +31,11 @@ func Private(h http.Handler) http.Handler {
 	)
 }
 
-func Protected(h http.Handler) http.Handler {
+// Protected requires client authentication with the given scheme
+// (see config.ClientAuth*) in front of a route group.
+func Protected(h http.Handler, scheme string) http.Handler {
 	return Chain(
 		h,
-		APIKey,
+		ClientAuth(scheme),
 	)
 }
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/rbac"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+)
+
+// RequireRole allows the request through only if the JWT role is one of roles.
+// It must run after JWT.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				response.Error(w, apperr.ErrUnauthorized)
+				return
+			}
+
+			if !slices.Contains(roles, claims.Role) {
+				forbidden(w, apperr.ErrInsufficientRole)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission allows the request through only if the JWT role has been
+// granted every one of perms in the rbac table. It must run after JWT.
+func RequirePermission(perms ...rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				response.Error(w, apperr.ErrUnauthorized)
+				return
+			}
+
+			for _, p := range perms {
+				if !rbac.Can(claims.Role, p) {
+					forbidden(w, apperr.ErrPermissionDenied)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forbidden(w http.ResponseWriter, err *apperr.AppError) {
+	response.Forbidden(response.SendParams{
+		W:       w,
+		Message: err.Message,
+		Data:    err,
+	})
+}
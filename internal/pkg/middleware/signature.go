@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/signing"
+)
+
+// maxSignedBody bounds how much of a signed request is buffered to hash it
+const maxSignedBody = 10 << 20
+
+// Signature authenticates server-to-server callers by an HMAC-SHA256 signature
+// over the request (see signing.StringToSign).
+func Signature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if isClientAuthExempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+		if err != nil {
+			response.Error(w, apperr.ErrBadRequest)
+			return
+		}
+		if len(body) > maxSignedBody {
+			response.Error(w, apperr.ErrBodyTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		clientID, err := signing.Default().Verify(r.Context(), r, signedPath(r), body)
+		if err != nil {
+			var appErr *apperr.AppError
+			if !errors.As(err, &appErr) {
+				slog.Error("signature_check_failed", "error", err)
+				response.InternalError(response.SendParams{W: w})
+				return
+			}
+			response.Error(w, appErr)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), constants.SigningClientContextKey, clientID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SigningClientFromContext returns the client ID stored by the Signature middleware
+func SigningClientFromContext(ctx context.Context) (string, bool) {
+	clientID, ok := ctx.Value(constants.SigningClientContextKey).(string)
+	return clientID, ok
+}
+
+// signedPath is the path the client called. r.URL.Path may already have had
+// route prefixes stripped, so it is taken from the original request URI.
+func signedPath(r *http.Request) string {
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		return u.EscapedPath()
+	}
+	return r.URL.EscapedPath()
+}
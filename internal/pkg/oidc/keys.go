@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+)
+
+const (
+	// keysMaxAge is how long a fetched JWKS is trusted before it is refreshed
+	keysMaxAge = time.Hour
+	// keysMinRefresh limits refetching when tokens name a kid we do not know
+	keysMinRefresh = time.Minute
+)
+
+type providerKey struct {
+	alg    string
+	public crypto.PublicKey
+}
+
+// keyCache holds a provider's signing keys by kid. Providers rotate keys by
+// publishing the new one before using it, so an unknown kid triggers a
+// refresh (at most once per keysMinRefresh).
+type keyCache struct {
+	provider *Provider
+	uri      string
+
+	mu        sync.Mutex
+	keys      map[string]providerKey
+	fetchedAt time.Time
+}
+
+func newKeyCache(p *Provider, uri string) *keyCache {
+	return &keyCache{provider: p, uri: uri}
+}
+
+// get returns the key for kid. An empty kid is accepted when the provider
+// publishes exactly one key.
+func (c *keyCache) get(ctx context.Context, kid string) (providerKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetchedAt) > keysMaxAge {
+		// Keep using the old keys if the provider is briefly unreachable
+		if err := c.refresh(ctx); err != nil && c.keys == nil {
+			return providerKey{}, err
+		}
+	}
+
+	if k, ok := c.lookup(kid); ok {
+		return k, nil
+	}
+
+	if time.Since(c.fetchedAt) > keysMinRefresh {
+		if err := c.refresh(ctx); err != nil {
+			return providerKey{}, err
+		}
+		if k, ok := c.lookup(kid); ok {
+			return k, nil
+		}
+	}
+
+	return providerKey{}, fmt.Errorf("unknown key id %q", kid)
+}
+
+func (c *keyCache) lookup(kid string) (providerKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, k := range c.keys {
+			return k, true
+		}
+	}
+	k, ok := c.keys[kid]
+	return k, ok
+}
+
+func (c *keyCache) refresh(ctx context.Context) error {
+	var set utils.JWKSet
+	if err := c.provider.getJSON(ctx, c.uri, &set); err != nil {
+		return fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]providerKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		pub, alg, err := jwk.PublicKey()
+		if err != nil {
+			// One odd key should not take the provider down
+			slog.Warn("oidc_jwk_skipped", "provider", c.provider.Name, "kid", jwk.Kid, "error", err)
+			continue
+		}
+		if jwk.Alg != "" && jwk.Alg != alg {
+			continue
+		}
+		keys[jwk.Kid] = providerKey{alg: alg, public: pub}
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
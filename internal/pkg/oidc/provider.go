@@ -0,0 +1,184 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// maxResponseSize caps what we read from a provider
+const maxResponseSize = 1 << 20
+
+// Metadata is the part of the discovery document we use
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect identity provider we act as a relying party
+// for. Discovery happens on first use and is cached, so an unreachable
+// provider does not stop the server from starting.
+type Provider struct {
+	Name   string
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+	leeway time.Duration
+
+	mu   sync.Mutex
+	meta *Metadata
+	keys *keyCache
+}
+
+// NewProvider builds a provider. client is used for every request to the
+// provider, which lets tests point it at a local stub issuer.
+func NewProvider(name string, cfg config.OIDCProviderConfig, client *http.Client, leeway time.Duration) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Provider{
+		Name:   name,
+		cfg:    cfg,
+		client: client,
+		leeway: leeway,
+	}
+}
+
+// metadata fetches and validates the discovery document once
+func (p *Provider) metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.Issuer, "/")
+
+	var meta Metadata
+	if err := p.getJSON(ctx, issuer+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+
+	// OIDC Discovery 4.3: the document must be for the issuer we asked about
+	if strings.TrimSuffix(meta.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery: issuer %q does not match %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("discovery: document is missing endpoints")
+	}
+
+	p.meta = &meta
+	p.keys = newKeyCache(p, meta.JWKSURI)
+	return p.meta, nil
+}
+
+// AuthCodeURL returns the provider URL to send the user agent to. The
+// challenge is the S256 PKCE challenge of the verifier later passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("authorization endpoint: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token.
+// nonce is the value sent in the authorization request.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*IDToken, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	// Confidential clients use client_secret_basic, public ones send their ID
+	basic := p.cfg.ClientSecret != ""
+	if !basic {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basic {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.doJSON(req, &token); err != nil {
+		if token.Error != "" {
+			return nil, fmt.Errorf("token endpoint: %s", strings.TrimSpace(token.Error+" "+token.ErrorDescription))
+		}
+		return nil, fmt.Errorf("token endpoint: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token endpoint: no id_token in response")
+	}
+
+	return p.verify(ctx, meta, token.IDToken, nonce)
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return p.doJSON(req, dst)
+}
+
+// doJSON decodes the response body into dst. On a non-2xx status the body is
+// still decoded (token errors are JSON) but an error is returned.
+func (p *Provider) doJSON(req *http.Request, dst any) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	decodeErr := json.NewDecoder(io.LimitReader(res.Body, maxResponseSize)).Decode(dst)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return decodeErr
+}
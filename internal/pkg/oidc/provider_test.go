@@ -0,0 +1,308 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+)
+
+const (
+	testClientID = "app"
+	testNonce    = "nonce-1"
+)
+
+type issuerKey struct {
+	kid string
+	key *ecdsa.PrivateKey
+}
+
+func newIssuerKey(t *testing.T, kid string) issuerKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return issuerKey{kid: kid, key: key}
+}
+
+func (k issuerKey) jwk() utils.JWK {
+	return utils.JWK{
+		Kty: "EC",
+		Kid: k.kid,
+		Use: "sig",
+		Alg: utils.AlgES256,
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(k.key.PublicKey.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(k.key.PublicKey.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// stubIssuer is an OpenID provider serving discovery, a JWKS and a token
+// endpoint that hands out whatever ID token the test set
+type stubIssuer struct {
+	*httptest.Server
+
+	advertised string // issuer in the discovery document, defaults to URL
+
+	mu       sync.Mutex
+	keys     []issuerKey
+	idToken  string
+	tokenReq url.Values
+
+	discoveryHits atomic.Int32
+	jwksHits      atomic.Int32
+}
+
+func newStubIssuer(t *testing.T, keys ...issuerKey) *stubIssuer {
+	s := &stubIssuer{keys: keys}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		s.discoveryHits.Add(1)
+		issuer := s.advertised
+		if issuer == "" {
+			issuer = s.URL
+		}
+		_ = json.NewEncoder(w).Encode(Metadata{
+			Issuer:                issuer,
+			AuthorizationEndpoint: s.URL + "/authorize",
+			TokenEndpoint:         s.URL + "/token",
+			JWKSURI:               s.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		s.jwksHits.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		set := utils.JWKSet{}
+		for _, k := range s.keys {
+			set.Keys = append(set.Keys, k.jwk())
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.tokenReq = r.PostForm
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": s.idToken})
+	})
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubIssuer) setKeys(keys ...issuerKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *stubIssuer) setIDToken(raw string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.idToken = raw
+}
+
+func (s *stubIssuer) provider() *Provider {
+	return NewProvider("stub", config.OIDCProviderConfig{
+		Issuer:      s.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost:3000/callback",
+		Scopes:      []string{"email", "profile"},
+	}, s.Client(), 0)
+}
+
+// validClaims is an ID token the stub issuer would legitimately issue to us
+func (s *stubIssuer) validClaims() *IDToken {
+	now := time.Now()
+	return &IDToken{
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Nonce:         testNonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.URL,
+			Subject:   "sub-1",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	}
+}
+
+func sign(t *testing.T, k issuerKey, claims *IDToken) string {
+	t.Helper()
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	tok.Header["kid"] = k.kid
+	raw, err := tok.SignedString(k.key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return raw
+}
+
+func TestProviderDiscovery(t *testing.T) {
+	s := newStubIssuer(t, newIssuerKey(t, "k1"))
+	p := s.provider()
+
+	for range 2 {
+		raw, err := p.AuthCodeURL(context.Background(), "state-1", testNonce, "challenge-1")
+		if err != nil {
+			t.Fatalf("AuthCodeURL: %v", err)
+		}
+		u, _ := url.Parse(raw)
+		if got := u.Scheme + "://" + u.Host + u.Path; got != s.URL+"/authorize" {
+			t.Errorf("endpoint = %q, want %q", got, s.URL+"/authorize")
+		}
+
+		q := u.Query()
+		want := map[string]string{
+			"response_type":         "code",
+			"client_id":             testClientID,
+			"scope":                 "openid email profile",
+			"state":                 "state-1",
+			"nonce":                 testNonce,
+			"code_challenge":        "challenge-1",
+			"code_challenge_method": "S256",
+		}
+		for k, v := range want {
+			if q.Get(k) != v {
+				t.Errorf("%s = %q, want %q", k, q.Get(k), v)
+			}
+		}
+	}
+
+	if n := s.discoveryHits.Load(); n != 1 {
+		t.Errorf("discovery fetched %d times, want 1", n)
+	}
+}
+
+func TestProviderDiscoveryIssuerMismatch(t *testing.T) {
+	s := newStubIssuer(t)
+	s.advertised = "https://evil.example.com"
+
+	_, err := s.provider().AuthCodeURL(context.Background(), "s", "n", "c")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("AuthCodeURL error = %v, want issuer mismatch", err)
+	}
+}
+
+func TestExchangeVerifiesIDToken(t *testing.T) {
+	key := newIssuerKey(t, "k1")
+	other := newIssuerKey(t, "k1") // same kid, key the issuer never published
+	s := newStubIssuer(t, key)
+
+	tests := []struct {
+		name    string
+		key     issuerKey
+		mutate  func(c *IDToken)
+		noNonce bool // verify as if the login had no nonce
+		wantErr string
+	}{
+		{name: "valid", key: key, mutate: func(c *IDToken) {}},
+		{name: "azp matches with several audiences", key: key, mutate: func(c *IDToken) {
+			c.Audience = jwt.ClaimStrings{testClientID, "other"}
+			c.AuthorizedParty = testClientID
+		}},
+		{name: "bad issuer", key: key, mutate: func(c *IDToken) { c.Issuer = "https://evil.example.com" }, wantErr: "invalid issuer"},
+		{name: "bad audience", key: key, mutate: func(c *IDToken) { c.Audience = jwt.ClaimStrings{"other"} }, wantErr: "invalid audience"},
+		{name: "several audiences without azp", key: key, mutate: func(c *IDToken) {
+			c.Audience = jwt.ClaimStrings{testClientID, "other"}
+		}, wantErr: "azp does not match"},
+		{name: "azp for another client", key: key, mutate: func(c *IDToken) { c.AuthorizedParty = "other" }, wantErr: "azp does not match"},
+		{name: "nonce mismatch", key: key, mutate: func(c *IDToken) { c.Nonce = "replayed" }, wantErr: "nonce mismatch"},
+		{name: "no nonce expected", key: key, mutate: func(c *IDToken) {}, noNonce: true, wantErr: "nonce mismatch"},
+		{name: "expired", key: key, mutate: func(c *IDToken) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		}, wantErr: "token is expired"},
+		{name: "no expiry", key: key, mutate: func(c *IDToken) { c.ExpiresAt = nil }, wantErr: "exp claim is required"},
+		{name: "no subject", key: key, mutate: func(c *IDToken) { c.Subject = "" }, wantErr: "no subject"},
+		{name: "bad signature", key: other, mutate: func(c *IDToken) {}, wantErr: "signature is invalid"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := s.validClaims()
+			tc.mutate(claims)
+			s.setIDToken(sign(t, tc.key, claims))
+
+			nonce := testNonce
+			if tc.noNonce {
+				nonce = ""
+			}
+
+			got, err := s.provider().Exchange(context.Background(), "code-1", "verifier-1", nonce)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Exchange error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if got.Subject != "sub-1" || got.Email != "alice@example.com" {
+				t.Errorf("claims = %+v", got)
+			}
+
+			s.mu.Lock()
+			form := s.tokenReq
+			s.mu.Unlock()
+			if form.Get("grant_type") != "authorization_code" || form.Get("code") != "code-1" ||
+				form.Get("code_verifier") != "verifier-1" || form.Get("client_id") != testClientID {
+				t.Errorf("token request = %v", form)
+			}
+		})
+	}
+}
+
+func TestExchangeKeyRotation(t *testing.T) {
+	old, next := newIssuerKey(t, "k1"), newIssuerKey(t, "k2")
+	s := newStubIssuer(t, old)
+	p := s.provider()
+
+	s.setIDToken(sign(t, old, s.validClaims()))
+	if _, err := p.Exchange(context.Background(), "c", "v", testNonce); err != nil {
+		t.Fatalf("Exchange with the first key: %v", err)
+	}
+
+	// A new kid shortly after a fetch is not refetched, to stop tokens with
+	// random kids from hammering the provider
+	s.setKeys(old, next)
+	s.setIDToken(sign(t, next, s.validClaims()))
+	if _, err := p.Exchange(context.Background(), "c", "v", testNonce); err == nil || !strings.Contains(err.Error(), "unknown key id") {
+		t.Fatalf("Exchange error = %v, want unknown key id", err)
+	}
+	if n := s.jwksHits.Load(); n != 1 {
+		t.Fatalf("jwks fetched %d times, want 1", n)
+	}
+
+	p.keys.mu.Lock()
+	p.keys.fetchedAt = time.Now().Add(-2 * keysMinRefresh)
+	p.keys.mu.Unlock()
+
+	if _, err := p.Exchange(context.Background(), "c", "v", testNonce); err != nil {
+		t.Fatalf("Exchange with the rotated key: %v", err)
+	}
+	if n := s.jwksHits.Load(); n != 2 {
+		t.Errorf("jwks fetched %d times, want 2", n)
+	}
+}
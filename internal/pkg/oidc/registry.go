@@ -0,0 +1,55 @@
+package oidc
+
+import (
+	"sync"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// Registry is the set of providers users may sign in with, by name
+type Registry struct {
+	providers map[string]*Provider
+}
+
+func NewRegistry(providers ...*Provider) *Registry {
+	r := &Registry{providers: make(map[string]*Provider, len(providers))}
+	for _, p := range providers {
+		r.providers[p.Name] = p
+	}
+	return r
+}
+
+// Get returns the named provider, or false if it is not configured
+func (r *Registry) Get(name string) (*Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+var (
+	defaultRegistry *Registry
+	defaultMu       sync.Mutex
+)
+
+// Default returns the registry used by the services. Unless SetDefault was
+// called it holds the providers configured in OIDC_PROVIDERS.
+func Default() *Registry {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultRegistry == nil {
+		cfg := config.Get()
+		var providers []*Provider
+		for name, pc := range cfg.OIDC.Providers {
+			providers = append(providers, NewProvider(name, pc, nil, cfg.JWT.Leeway))
+		}
+		defaultRegistry = NewRegistry(providers...)
+	}
+	return defaultRegistry
+}
+
+// SetDefault replaces the registry used by the services
+func SetDefault(r *Registry) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRegistry = r
+}
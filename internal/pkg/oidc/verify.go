@@ -0,0 +1,66 @@
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+)
+
+// IDToken holds the verified claims of an ID token we care about
+type IDToken struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// verify checks an ID token as required by OIDC Core 3.1.3.7: signed by a
+// key from the provider's JWKS, issued by the provider for us, unexpired and
+// carrying the nonce of this login.
+func (p *Provider) verify(ctx context.Context, meta *Metadata, raw, nonce string) (*IDToken, error) {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	var claims IDToken
+	_, err := jwt.ParseWithClaims(raw, &claims,
+		func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			k, err := keys.get(ctx, kid)
+			if err != nil {
+				return nil, err
+			}
+			if t.Method.Alg() != k.alg {
+				return nil, errors.New("unexpected signing method")
+			}
+			return k.public, nil
+		},
+		jwt.WithValidMethods([]string{utils.AlgRS256, utils.AlgES256, utils.AlgEdDSA}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(p.leeway),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+	if (len(claims.Audience) > 1 || claims.AuthorizedParty != "") && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("id token azp does not match client")
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("id token nonce mismatch")
+	}
+
+	return &claims, nil
+}
@@ -0,0 +1,115 @@
+package pagination
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Setenv("JWT_ACCESS_EXPIRES_IN", "15m")
+	config.Load()
+	os.Exit(m.Run())
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	scope := Scope(map[string]any{"status": 1, "sort": []string{"-created_at"}})
+	at := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	for _, backward := range []bool{false, true} {
+		c := Cursor{CreatedAt: at, ID: 42, Backward: backward, Scope: scope}
+
+		got, err := Decode(c.Encode(), scope)
+		if err != nil {
+			t.Fatalf("backward=%v: Decode: %v", backward, err)
+		}
+		if !got.CreatedAt.Equal(at) || got.ID != 42 || got.Backward != backward || got.Scope != scope {
+			t.Errorf("backward=%v: Decode = %+v, want %+v", backward, *got, c)
+		}
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	scope := Scope(map[string]any{"status": 1})
+	valid := Cursor{CreatedAt: time.Unix(1700000000, 0).UTC(), ID: 7, Scope: scope}.Encode()
+	payload, mac, _ := strings.Cut(valid, ".")
+
+	// The same position claiming to be a backward cursor, with the old MAC
+	flipped, _ := json.Marshal(Cursor{CreatedAt: time.Unix(1700000000, 0).UTC(), ID: 7, Backward: true, Scope: scope})
+	// Another position, with the old MAC
+	moved, _ := json.Marshal(Cursor{CreatedAt: time.Unix(1700000000, 0).UTC(), ID: 8, Scope: scope})
+
+	tests := []struct {
+		name   string
+		cursor string
+		scope  string
+	}{
+		{name: "other scope", cursor: valid, scope: Scope(map[string]any{"status": 2})},
+		{name: "no scope", cursor: valid, scope: ""},
+		{name: "direction flipped", cursor: cursorEncoding.EncodeToString(flipped) + "." + mac, scope: scope},
+		{name: "id changed", cursor: cursorEncoding.EncodeToString(moved) + "." + mac, scope: scope},
+		{name: "mac changed", cursor: payload + "." + flipByte(mac), scope: scope},
+		{name: "mac truncated", cursor: payload + "." + mac[:len(mac)-2], scope: scope},
+		{name: "no mac", cursor: payload, scope: scope},
+		{name: "empty mac", cursor: payload + ".", scope: scope},
+		{name: "not base64", cursor: "!!!." + mac, scope: scope},
+		{name: "empty", cursor: "", scope: scope},
+		{name: "signed json that is not a cursor", cursor: signed([]byte(`[1,2]`)), scope: scope},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if c, err := Decode(tc.cursor, tc.scope); err != ErrInvalidCursor {
+				t.Errorf("Decode = (%+v, %v), want ErrInvalidCursor", c, err)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsOtherSecret(t *testing.T) {
+	scope := Scope("q")
+	c := Cursor{CreatedAt: time.Now().UTC(), ID: 1, Scope: scope}.Encode()
+
+	t.Cleanup(func() { config.Get().JWT.Secret = "test-secret" })
+	config.Get().JWT.Secret = "rotated-secret"
+
+	if _, err := Decode(c, scope); err != ErrInvalidCursor {
+		t.Errorf("Decode = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestScope(t *testing.T) {
+	type query struct {
+		Status int      `json:"status"`
+		Sort   []string `json:"sort"`
+	}
+
+	a := Scope(query{Status: 1, Sort: []string{"-created_at"}})
+	if a != Scope(query{Status: 1, Sort: []string{"-created_at"}}) {
+		t.Error("Scope is not deterministic")
+	}
+	if a == Scope(query{Status: 1, Sort: []string{"created_at"}}) {
+		t.Error("sort order does not change the scope")
+	}
+	if a == Scope(query{Status: 2, Sort: []string{"-created_at"}}) {
+		t.Error("filters do not change the scope")
+	}
+}
+
+// flipByte changes the first character of a base64url string
+func flipByte(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
+
+// signed encodes an arbitrary payload with a valid MAC
+func signed(payload []byte) string {
+	return cursorEncoding.EncodeToString(payload) + "." + cursorEncoding.EncodeToString(sign(payload))
+}
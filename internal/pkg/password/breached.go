@@ -0,0 +1,133 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// BreachedList tells whether a password is known from data breaches
+type BreachedList interface {
+	Contains(ctx context.Context, password string) (bool, error)
+}
+
+// prefixLen is the SHA-1 prefix a range lookup is keyed by, as in the
+// k-anonymity range API of Have I Been Pwned
+const prefixLen = 5
+
+// FileList looks passwords up in a local copy of a breached-password list:
+// one uppercase SHA-1 hex hash per line, optionally followed by ":count", the
+// file sorted by hash (the "ordered by hash" download of Pwned Passwords).
+// Lookups binary-search the file, so it is never loaded into memory.
+type FileList struct {
+	path string
+}
+
+func NewFileList(path string) *FileList {
+	return &FileList{path: path}
+}
+
+func (l *FileList) Contains(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := l.Range(ctx, hash[:prefixLen])
+	if err != nil {
+		return false, err
+	}
+	for _, s := range suffixes {
+		if s == hash[prefixLen:] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Range returns the hash suffixes of every entry starting with prefix, the
+// same answer the online range API gives for it
+func (l *FileList) Range(ctx context.Context, prefix string) ([]string, error) {
+	prefix = strings.ToUpper(prefix)
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the smallest offset whose following line sorts at or after prefix
+	lo, hi := int64(0), info.Size()
+	for lo < hi {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		mid := lo + (hi-lo)/2
+		_, line, err := lineAfter(f, mid)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" || line >= prefix {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	start, _, err := lineAfter(f, lo)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var suffixes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(scanner.Text())), ":")
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes = append(suffixes, hash[len(prefix):])
+	}
+	return suffixes, scanner.Err()
+}
+
+// lineAfter returns the first line starting at or after off, with its
+// offset. At the end of the file the line is empty.
+func lineAfter(f *os.File, off int64) (int64, string, error) {
+	start := off
+	if off > 0 {
+		// Begin one byte early so a line starting exactly at off is kept
+		start = off - 1
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return 0, "", err
+	}
+	r := bufio.NewReader(f)
+
+	if off > 0 {
+		skipped, err := r.ReadString('\n')
+		if err == io.EOF {
+			return start + int64(len(skipped)), "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, strings.ToUpper(strings.TrimSpace(line)), nil
+}
@@ -0,0 +1,161 @@
+package password
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// breachedLines is a small "ordered by hash" list. The first and last
+// prefixes each occur once, 5BAA6 spans several lines, and 5BAA61E4... is
+// SHA-1("password").
+var breachedLines = []string{
+	"00000A1B2C3D4E5F60718293A4B5C6D7E8F90123:12",
+	"12345F00DF00DF00DF00DF00DF00DF00DF00DF00:1",
+	"5BAA6000000000000000000000000000000000AA:3",
+	"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:52256179",
+	"5BAA6FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+	"A94A8FE5CCB19BA61C4C0873D391E987982FBBD3:86",
+	"FFFFF0123456789ABCDEF0123456789ABCDEF012:2",
+}
+
+func writeList(t *testing.T, content string) *FileList {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return NewFileList(path)
+}
+
+func TestFileListRange(t *testing.T) {
+	files := map[string]string{
+		"lf":                strings.Join(breachedLines, "\n") + "\n",
+		"crlf":              strings.Join(breachedLines, "\r\n") + "\r\n",
+		"no final newline":  strings.Join(breachedLines, "\n"),
+		"lowercase in file": strings.ToLower(strings.Join(breachedLines, "\n")),
+	}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{prefix: "00000", want: []string{"A1B2C3D4E5F60718293A4B5C6D7E8F90123"}},
+		{prefix: "FFFFF", want: []string{"0123456789ABCDEF0123456789ABCDEF012"}},
+		{prefix: "5BAA6", want: []string{
+			"000000000000000000000000000000000AA",
+			"1E4C9B93F3F0682250B6CF8331B7EE68FD8",
+			"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+		}},
+		{prefix: "5baa6", want: []string{
+			"000000000000000000000000000000000AA",
+			"1E4C9B93F3F0682250B6CF8331B7EE68FD8",
+			"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+		}},
+		{prefix: "A94A8", want: []string{"FE5CCB19BA61C4C0873D391E987982FBBD3"}},
+		{prefix: "5BAA5"}, // sorts just before a present range
+		{prefix: "5BAA7"}, // sorts just after a present range
+		{prefix: "FFFFE"}, // between the last two entries
+		{prefix: "00001"}, // right after the first entry
+	}
+
+	for name, content := range files {
+		l := writeList(t, content)
+		for _, tc := range tests {
+			got, err := l.Range(context.Background(), tc.prefix)
+			if err != nil {
+				t.Fatalf("%s: Range(%s): %v", name, tc.prefix, err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("%s: Range(%s) = %v, want %v", name, tc.prefix, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestFileListRangeEdgeFiles(t *testing.T) {
+	ctx := context.Background()
+
+	if got, err := writeList(t, "").Range(ctx, "5BAA6"); err != nil || got != nil {
+		t.Errorf("empty file: (%v, %v), want no suffixes", got, err)
+	}
+
+	one := writeList(t, breachedLines[3]+"\n")
+	if got, _ := one.Range(ctx, "5BAA6"); len(got) != 1 {
+		t.Errorf("single line: %v, want the entry", got)
+	}
+	if got, _ := one.Range(ctx, "00000"); got != nil {
+		t.Errorf("single line, earlier prefix: %v, want none", got)
+	}
+	if got, _ := one.Range(ctx, "FFFFF"); got != nil {
+		t.Errorf("single line, later prefix: %v, want none", got)
+	}
+
+	if _, err := NewFileList(filepath.Join(t.TempDir(), "missing.txt")).Range(ctx, "5BAA6"); err == nil {
+		t.Error("missing file: no error")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := writeList(t, strings.Join(breachedLines, "\n")).Range(cancelled, "5BAA6"); err != context.Canceled {
+		t.Errorf("cancelled: %v, want context.Canceled", err)
+	}
+}
+
+func TestLineAfter(t *testing.T) {
+	content := "AAA\nBBBB\nCC\n"
+	path := filepath.Join(t.TempDir(), "lines.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		off       int64
+		wantStart int64
+		wantLine  string
+	}{
+		{off: 0, wantStart: 0, wantLine: "AAA"},
+		{off: 1, wantStart: 4, wantLine: "BBBB"}, // inside the first line
+		{off: 3, wantStart: 4, wantLine: "BBBB"}, // on its newline
+		{off: 4, wantStart: 4, wantLine: "BBBB"}, // exactly at a line start
+		{off: 6, wantStart: 9, wantLine: "CC"},   // inside a later line
+		{off: 9, wantStart: 9, wantLine: "CC"},   // the last line start
+		{off: 10, wantStart: 12, wantLine: ""},   // inside the last line
+		{off: 12, wantStart: 12, wantLine: ""},   // end of file
+	}
+
+	for _, tc := range tests {
+		start, line, err := lineAfter(f, tc.off)
+		if err != nil {
+			t.Fatalf("lineAfter(%d): %v", tc.off, err)
+		}
+		if start != tc.wantStart || line != tc.wantLine {
+			t.Errorf("lineAfter(%d) = (%d, %q), want (%d, %q)", tc.off, start, line, tc.wantStart, tc.wantLine)
+		}
+	}
+}
+
+func TestFileListContains(t *testing.T) {
+	l := writeList(t, strings.Join(breachedLines, "\n")+"\n")
+
+	for password, want := range map[string]bool{
+		"password": true,  // 5BAA61E4...
+		"test":     true,  // A94A8FE5...
+		"Password": false, // different hash
+		"":         false,
+	} {
+		got, err := l.Contains(context.Background(), password)
+		if err != nil || got != want {
+			t.Errorf("Contains(%q) = (%v, %v), want %v", password, got, err, want)
+		}
+	}
+}
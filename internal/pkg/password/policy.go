@@ -0,0 +1,191 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+)
+
+// Rules a password can violate
+const (
+	RuleMinLength     = "min_length"
+	RuleMaxLength     = "max_length"
+	RuleUpper         = "uppercase"
+	RuleLower         = "lowercase"
+	RuleDigit         = "digit"
+	RuleSymbol        = "symbol"
+	RuleContainsName  = "contains_username"
+	RuleContainsEmail = "contains_email"
+	RuleReused        = "reused"
+	RuleBreached      = "breached"
+)
+
+// Shorter usernames or email names would reject too many passwords
+const minIdentifierLength = 3
+
+// Violation is one rule a password failed
+type Violation struct {
+	Rule    string `json:"rule" example:"min_length"`
+	Message string `json:"message" example:"Password must be at least 8 characters long"`
+}
+
+// Candidate is a new password together with what it must not resemble
+type Candidate struct {
+	Password string
+	Username string
+	Email    string
+
+	// PreviousHashes are the hashes of the passwords that may not be reused,
+	// newest first, the current one included
+	PreviousHashes []string
+}
+
+// Policy describes the passwords users may choose
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	History       int          // how many PreviousHashes are checked; 0 disables
+	Breached      BreachedList // nil disables the breached-password check
+}
+
+// Validate checks a candidate against every rule. A failing candidate yields
+// apperr.ErrPasswordPolicy carrying all violations, so the client can show
+// them at once.
+func (p *Policy) Validate(ctx context.Context, c Candidate) error {
+	violations := p.Check(ctx, c)
+	if len(violations) > 0 {
+		return apperr.ErrPasswordPolicy.WithDetails(violations)
+	}
+	return nil
+}
+
+// Check returns the rules the candidate violates
+func (p *Policy) Check(ctx context.Context, c Candidate) []Violation {
+	var v []Violation
+	add := func(rule, format string, args ...any) {
+		v = append(v, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(c.Password)
+	if length < p.MinLength {
+		add(RuleMinLength, "Password must be at least %d characters long", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		// Nothing else is worth checking, and hashing huge inputs is costly
+		add(RuleMaxLength, "Password must be at most %d characters long", p.MaxLength)
+		return v
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range c.Password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		add(RuleUpper, "Password must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		add(RuleLower, "Password must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		add(RuleDigit, "Password must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		add(RuleSymbol, "Password must contain a symbol")
+	}
+
+	lowered := strings.ToLower(c.Password)
+	if containsIdentifier(lowered, c.Username) {
+		add(RuleContainsName, "Password must not contain your username")
+	}
+	local, _, _ := strings.Cut(c.Email, "@")
+	if containsIdentifier(lowered, local) {
+		add(RuleContainsEmail, "Password must not contain your email address")
+	}
+
+	if p.History > 0 {
+		previous := c.PreviousHashes
+		if len(previous) > p.History {
+			previous = previous[:p.History]
+		}
+		for _, hash := range previous {
+			if utils.ComparePassword(hash, c.Password) {
+				add(RuleReused, "Password must differ from your last %d passwords", p.History)
+				break
+			}
+		}
+	}
+
+	if p.Breached != nil {
+		// An unreadable list must not stop everyone from setting a password
+		breached, err := p.Breached.Contains(ctx, c.Password)
+		if err != nil {
+			slog.Error("breached_password_lookup_failed", "error", err)
+		} else if breached {
+			add(RuleBreached, "Password has appeared in a data breach, choose another one")
+		}
+	}
+
+	return v
+}
+
+func containsIdentifier(lowered, id string) bool {
+	id = strings.ToLower(strings.TrimSpace(id))
+	return utf8.RuneCountInString(id) >= minIdentifierLength && strings.Contains(lowered, id)
+}
+
+var (
+	defaultPolicy *Policy
+	defaultMu     sync.Mutex
+)
+
+// Default returns the policy applied when users set a password. Unless
+// SetDefault was called it is built from the PASSWORD_* settings.
+func Default() *Policy {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultPolicy == nil {
+		cfg := config.Get().Password
+		defaultPolicy = &Policy{
+			MinLength:     cfg.MinLength,
+			MaxLength:     cfg.MaxLength,
+			RequireUpper:  cfg.RequireUpper,
+			RequireLower:  cfg.RequireLower,
+			RequireDigit:  cfg.RequireDigit,
+			RequireSymbol: cfg.RequireSymbol,
+			History:       cfg.History,
+		}
+		if cfg.BreachedFile != "" {
+			defaultPolicy.Breached = NewFileList(cfg.BreachedFile)
+		}
+	}
+	return defaultPolicy
+}
+
+// SetDefault replaces the policy applied when users set a password
+func SetDefault(p *Policy) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultPolicy = p
+}
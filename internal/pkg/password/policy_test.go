@@ -0,0 +1,96 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// stubList is a BreachedList answering from a fixed set
+type stubList struct {
+	passwords []string
+	err       error
+}
+
+func (l stubList) Contains(_ context.Context, password string) (bool, error) {
+	return slices.Contains(l.passwords, password), l.err
+}
+
+func TestPolicyCheck(t *testing.T) {
+	hasher := utils.BcryptHasher{Cost: bcrypt.MinCost}
+	hash := func(p string) string {
+		h, err := hasher.Hash(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	oldest, older, current := hash("Oldest#pass1"), hash("Older#pass1"), hash("Current#pass1")
+
+	strict := Policy{MinLength: 8, MaxLength: 64, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		c      Candidate
+		want   []string
+	}{
+		{name: "meets every rule", policy: strict, c: Candidate{Password: "Corr3ct horse!"}},
+		{name: "too short", policy: strict, c: Candidate{Password: "Ab1!"}, want: []string{RuleMinLength}},
+		{name: "length counts runes", policy: Policy{MinLength: 4}, c: Candidate{Password: "äöüß"}},
+		{name: "too long stops checking", policy: strict, c: Candidate{Password: strings.Repeat("a", 65)}, want: []string{RuleMaxLength}},
+		{name: "no max length", policy: Policy{}, c: Candidate{Password: strings.Repeat("a", 1000)}},
+		{name: "every class missing", policy: strict, c: Candidate{Password: "        "}, want: []string{RuleUpper, RuleLower, RuleDigit}},
+		{name: "only lowercase", policy: strict, c: Candidate{Password: "abcdefgh"}, want: []string{RuleUpper, RuleDigit, RuleSymbol}},
+		{name: "non-ascii letters count", policy: Policy{RequireUpper: true, RequireLower: true}, c: Candidate{Password: "ÄÖÜäöü"}},
+		{name: "space is a symbol", policy: Policy{RequireSymbol: true}, c: Candidate{Password: "two words"}},
+		{name: "contains username", policy: Policy{}, c: Candidate{Password: "xxJohnDoexx", Username: "johndoe"}, want: []string{RuleContainsName}},
+		{name: "contains email name", policy: Policy{}, c: Candidate{Password: "Mary.Smith-2026", Email: "mary.smith@example.com"}, want: []string{RuleContainsEmail}},
+		{name: "domain alone is fine", policy: Policy{}, c: Candidate{Password: "example.com!", Email: "mary.smith@example.com"}},
+		{name: "short username ignored", policy: Policy{}, c: Candidate{Password: "bobcat-fever", Username: "bo"}},
+		{name: "reuses current password", policy: Policy{History: 3}, c: Candidate{Password: "Current#pass1", PreviousHashes: []string{current, older, oldest}}, want: []string{RuleReused}},
+		{name: "reuses password inside history", policy: Policy{History: 3}, c: Candidate{Password: "Oldest#pass1", PreviousHashes: []string{current, older, oldest}}, want: []string{RuleReused}},
+		{name: "reuse beyond history", policy: Policy{History: 2}, c: Candidate{Password: "Oldest#pass1", PreviousHashes: []string{current, older, oldest}}},
+		{name: "history disabled", policy: Policy{}, c: Candidate{Password: "Current#pass1", PreviousHashes: []string{current}}},
+		{name: "breached", policy: Policy{Breached: stubList{passwords: []string{"password1"}}}, c: Candidate{Password: "password1"}, want: []string{RuleBreached}},
+		{name: "breached list unavailable", policy: Policy{Breached: stubList{passwords: []string{"password1"}, err: errors.New("disk gone")}}, c: Candidate{Password: "password1"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, v := range tc.policy.Check(context.Background(), tc.c) {
+				if v.Message == "" {
+					t.Errorf("rule %s has no message", v.Rule)
+				}
+				got = append(got, v.Rule)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("violations = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	p := &Policy{MinLength: 8, RequireDigit: true}
+
+	if err := p.Validate(context.Background(), Candidate{Password: "longenough1"}); err != nil {
+		t.Fatalf("Validate = %v, want nil", err)
+	}
+
+	err := p.Validate(context.Background(), Candidate{Password: "short"})
+	var appErr *apperr.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperr.ErrPasswordPolicy.Code {
+		t.Fatalf("Validate = %v, want ErrPasswordPolicy", err)
+	}
+	if v, ok := appErr.Details.([]Violation); !ok || len(v) != 2 {
+		t.Errorf("details = %#v, want both violations", appErr.Details)
+	}
+}
@@ -0,0 +1,35 @@
+package rbac
+
+import "github.com/lakhan-purohit/net-http/internal/pkg/constants"
+
+// Permission is a single action a role may perform
+type Permission string
+
+const (
+	PermUserList   Permission = "users:list"
+	PermUserStats  Permission = "users:stats"
+	PermUserManage Permission = "users:manage"
+)
+
+// rolePermissions is the single source of truth for what each role may do.
+// Add new permissions here rather than checking roles in handlers.
+var rolePermissions = map[string][]Permission{
+	constants.RoleAdmin: {
+		PermUserList,
+		PermUserStats,
+		PermUserManage,
+	},
+	constants.RoleUser: {
+		PermUserList,
+	},
+}
+
+// Can reports whether the role has been granted the permission
+func Can(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package request
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+var (
+	trustedNets     []*net.IPNet
+	trustedNetsOnce sync.Once
+)
+
+// ClientIP returns the IP of the client that made the request.
+// Forwarding headers are only honoured when the direct peer is a trusted proxy
+// (TRUSTED_PROXIES), otherwise anyone could spoof their address.
+func ClientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(remote) {
+		return remote
+	}
+
+	// Walk X-Forwarded-For from the right, skipping our own proxies
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(hops[i])
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			if !isTrustedProxy(ip) {
+				return ip
+			}
+		}
+	}
+
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+
+	return remote
+}
+
+func isTrustedProxy(ip string) bool {
+	trustedNetsOnce.Do(loadTrustedProxies)
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range trustedNets {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadTrustedProxies() {
+	for _, entry := range config.Get().App.TrustedProxies {
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Printf("ignoring invalid trusted proxy %q: %v", entry, err)
+			continue
+		}
+		trustedNets = append(trustedNets, n)
+	}
+}
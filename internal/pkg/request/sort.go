@@ -0,0 +1,35 @@
+package request
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseSort reads a sort parameter such as "-created_at,username": fields in
+// order of precedence, each descending when prefixed with "-". Only the
+// allowed fields are accepted, at most once each. The result keeps that
+// notation, e.g. []string{"-created_at", "username"}.
+func ParseSort(raw string, allowed ...string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var (
+		fields []string
+		seen   = map[string]bool{}
+	)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		name := strings.TrimPrefix(part, "-")
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("cannot sort by %q, use one of: %s", name, strings.Join(allowed, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("sort field %q given twice", name)
+		}
+		seen[name] = true
+		fields = append(fields, part)
+	}
+	return fields, nil
+}
@@ -3,63 +3,116 @@ package request
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"mime"
 	"net/http"
+	"net/url"
 	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
-	"strconv"
 )
 
 var validate = validator.New()
 
-// PaginationRequest is a common struct for pagination
+// PaginationRequest is a common struct for pagination. Lists that support
+// it page by Cursor (see pagination.Cursor) instead of Offset; the two
+// cannot be combined.
 type PaginationRequest struct {
-	Limit  int `query:"limit" validate:"omitempty,min=1,max=100"`
-	Offset int `query:"offset" validate:"omitempty,min=0"`
+	Limit        int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Offset       int    `query:"offset" validate:"omitempty,min=0,excluded_with=Cursor"`
+	Cursor       string `query:"cursor" validate:"omitempty,max=512"`
+	IncludeTotal bool   `query:"include_total"`
 }
 
-// BindQuery populates a struct from URL query parameters
+// BindQuery populates a struct from URL query parameters. Besides strings,
+// ints and bools it fills time.Time (RFC 3339, or YYYY-MM-DD in UTC) and
+// pointers to those, which stay nil when the parameter is absent. Embedded
+// structs such as PaginationRequest are bound too.
 func BindQuery(r *http.Request, dst any) error {
-	v := reflect.ValueOf(dst).Elem()
+	if err := bindQueryValues(r.URL.Query(), reflect.ValueOf(dst).Elem()); err != nil {
+		return err
+	}
+
+	return validate.Struct(dst)
+}
+
+func bindQueryValues(q url.Values, v reflect.Value) error {
 	t := v.Type()
 
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
+		fieldV := v.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if err := bindQueryValues(q, fieldV); err != nil {
+				return err
+			}
+			continue
+		}
+
 		tag := f.Tag.Get("query")
 		if tag == "" {
 			continue
 		}
 
-		val := r.URL.Query().Get(tag)
+		val := q.Get(tag)
 		if val == "" {
 			continue
 		}
 
-		fieldV := v.Field(i)
 		if !fieldV.CanSet() {
 			continue
 		}
 
-		switch fieldV.Kind() {
-		case reflect.String:
-			fieldV.SetString(val)
-		case reflect.Int:
-			if iVal, err := strconv.Atoi(val); err == nil {
-				fieldV.SetInt(int64(iVal))
-			}
-		case reflect.Int64:
-			if iVal, err := strconv.ParseInt(val, 10, 64); err == nil {
-				fieldV.SetInt(iVal)
-			}
-		case reflect.Bool:
-			if bVal, err := strconv.ParseBool(val); err == nil {
-				fieldV.SetBool(bVal)
-			}
+		target := fieldV
+		if fieldV.Kind() == reflect.Ptr {
+			target = reflect.New(f.Type.Elem()).Elem()
+		}
+		if err := setQueryValue(target, val); err != nil {
+			return fmt.Errorf("invalid value for %s: %q", tag, val)
+		}
+		if fieldV.Kind() == reflect.Ptr {
+			fieldV.Set(target.Addr())
 		}
 	}
 
-	return validate.Struct(dst)
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func setQueryValue(v reflect.Value, val string) error {
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, val)
+		}
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Int, reflect.Int64:
+		iVal, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(iVal)
+	case reflect.Bool:
+		bVal, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		v.SetBool(bVal)
+	}
+	return nil
 }
 
 func ValidateStruct(v any) error {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/password"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
 )
 
@@ -40,20 +41,132 @@ type LoginResponse struct {
 	Result  model.User `json:"r"`
 }
 
+// MFAChallengeResponse is for Swagger documentation
+// @Description Login response when a second factor is required
+type MFAChallengeResponse struct {
+	Status  int                `json:"s" example:"1"`
+	Message string             `json:"m" example:"Two-factor authentication required"`
+	Result  model.MFAChallenge `json:"r"`
+}
+
+// MFASetupResponse is for Swagger documentation
+// @Description TOTP enrolment response
+type MFASetupResponse struct {
+	Status  int            `json:"s" example:"1"`
+	Message string         `json:"m" example:"Success"`
+	Result  model.MFASetup `json:"r"`
+}
+
+// MFARecoveryCodesResponse is for Swagger documentation
+// @Description 2FA enabled response with recovery codes
+type MFARecoveryCodesResponse struct {
+	Status  int                    `json:"s" example:"1"`
+	Message string                 `json:"m" example:"Success"`
+	Result  model.MFARecoveryCodes `json:"r"`
+}
+
+// OIDCStartResponse is for Swagger documentation
+// @Description External sign-in started
+type OIDCStartResponse struct {
+	Status  int             `json:"s" example:"1"`
+	Message string          `json:"m" example:"Success"`
+	Result  model.OIDCStart `json:"r"`
+}
+
+// UserResponse is for Swagger documentation
+// @Description Single user response
+type UserResponse struct {
+	Status  int        `json:"s" example:"1"`
+	Message string     `json:"m" example:"Success"`
+	Result  model.User `json:"r"`
+}
+
 // UserListResponse is for Swagger documentation
 // @Description Successful user list response
 type UserListResponse struct {
-	Status  int            `json:"s" example:"1"`
-	Message string         `json:"m" example:"Success"`
-	Result  []model.User `json:"r"`
+	Status  int              `json:"s" example:"1"`
+	Message string           `json:"m" example:"Success"`
+	Result  Page[model.User] `json:"r"`
 }
 
 // UserFullListResponse is for complex data examples
 // @Description Successful user list with stats response
 type UserFullListResponse struct {
-	Status  int                   `json:"s" example:"1"`
-	Message string                `json:"m" example:"Success"`
-	Result  []model.UserWithStats `json:"r"`
+	Status  int                       `json:"s" example:"1"`
+	Message string                    `json:"m" example:"Success"`
+	Result  Page[model.UserWithStats] `json:"r"`
+}
+
+// SessionListResponse is for Swagger documentation
+// @Description Active sessions of the current user
+type SessionListResponse struct {
+	Status  int             `json:"s" example:"1"`
+	Message string          `json:"m" example:"Success"`
+	Result  []model.Session `json:"r"`
+}
+
+// APIKeyResponse is for Swagger documentation
+// @Description Newly created API key (plaintext included once)
+type APIKeyResponse struct {
+	Status  int          `json:"s" example:"1"`
+	Message string       `json:"m" example:"API key created, store it now as it will not be shown again"`
+	Result  model.APIKey `json:"r"`
+}
+
+// APIKeyListResponse is for Swagger documentation
+// @Description API keys
+type APIKeyListResponse struct {
+	Status  int            `json:"s" example:"1"`
+	Message string         `json:"m" example:"Success"`
+	Result  []model.APIKey `json:"r"`
+}
+
+// OAuthClientResponse is for Swagger documentation
+// @Description Newly registered OAuth2 client (secret included once)
+type OAuthClientResponse struct {
+	Status  int               `json:"s" example:"1"`
+	Message string            `json:"m" example:"OAuth client registered"`
+	Result  model.OAuthClient `json:"r"`
+}
+
+// OAuthClientListResponse is for Swagger documentation
+// @Description OAuth2 clients
+type OAuthClientListResponse struct {
+	Status  int                 `json:"s" example:"1"`
+	Message string              `json:"m" example:"Success"`
+	Result  []model.OAuthClient `json:"r"`
+}
+
+// OAuthConsentResponse is for Swagger documentation
+// @Description Pending OAuth2 authorization
+type OAuthConsentResponse struct {
+	Status  int                `json:"s" example:"1"`
+	Message string             `json:"m" example:"Success"`
+	Result  model.OAuthConsent `json:"r"`
+}
+
+// OAuthRedirectResponse is for Swagger documentation
+// @Description OAuth2 authorization result
+type OAuthRedirectResponse struct {
+	Status  int                 `json:"s" example:"1"`
+	Message string              `json:"m" example:"Success"`
+	Result  model.OAuthRedirect `json:"r"`
+}
+
+// ImpersonationResponse is for Swagger documentation
+// @Description Impersonation token for a user
+type ImpersonationResponse struct {
+	Status  int                 `json:"s" example:"1"`
+	Message string              `json:"m" example:"Success"`
+	Result  model.Impersonation `json:"r"`
+}
+
+// StatusHistoryResponse is for Swagger documentation
+// @Description A user's status changes, newest first
+type StatusHistoryResponse struct {
+	Status  int                  `json:"s" example:"1"`
+	Message string               `json:"m" example:"Success"`
+	Result  []model.StatusChange `json:"r"`
 }
 
 // ErrorResponse is for Swagger documentation
@@ -63,6 +176,15 @@ type ErrorResponse struct {
 	Message string `json:"m" example:"Error message"`
 }
 
+// PasswordPolicyErrorResponse is for Swagger documentation
+// @Description Password rejected by the password policy, one entry per violated rule
+type PasswordPolicyErrorResponse struct {
+	Status  int                  `json:"s" example:"0"`
+	Message string               `json:"m" example:"Password does not meet the password policy"`
+	Code    string               `json:"c" example:"PASSWORD_POLICY"`
+	Result  []password.Violation `json:"r"`
+}
+
 // send is the core response writer (DRY, internal use only)
 func send(res SendParams, success bool, defaultStatus int, defaultMessage string) {
 	w := res.W
@@ -91,7 +213,7 @@ func send(res SendParams, success bool, defaultStatus int, defaultMessage string
 		// Check if data is an AppError to extract the code
 		if ae, ok := res.Data.(*apperr.AppError); ok {
 			payload.Code = ae.Code
-			payload.Result = nil // Don't send error object in Result field
+			payload.Result = ae.Details // Don't send error object in Result field, only its details
 		}
 	}
 
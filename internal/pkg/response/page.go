@@ -0,0 +1,21 @@
+package response
+
+// Page is the result of every paginated list: the items, the filters that
+// selected them and where to go next
+type Page[T any] struct {
+	Items      []T      `json:"items"`
+	Filters    any      `json:"filters,omitempty"`
+	Pagination PageInfo `json:"pagination"`
+}
+
+// PageInfo describes a page. NextCursor and PrevCursor are set when there
+// is a page in that direction and the list supports cursors for the chosen
+// sort order; pass them back as cursor to fetch it.
+type PageInfo struct {
+	Limit      int    `json:"limit" example:"10"`
+	Offset     int    `json:"offset,omitempty" example:"0"`
+	HasMore    bool   `json:"has_more" example:"true"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNi0wMS0wMVQwMDowMDowMFoiLCJpIjo0Mn0.c2lnbmF0dXJl"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty" example:"1234"` // only with include_total=true
+}
@@ -0,0 +1,121 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type userCutoff struct {
+	before    time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore is a process-local Store. Entries are evicted by a background
+// janitor once the token they refer to has expired.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	users    map[int64]userCutoff
+	sessions map[string]time.Time
+}
+
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		tokens:   make(map[string]time.Time),
+		users:    make(map[int64]userCutoff),
+		sessions: make(map[string]time.Time),
+	}
+	go s.janitor(cleanupInterval)
+	return s
+}
+
+func (s *MemoryStore) Revoke(_ context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" || time.Now().After(expiresAt) {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.tokens[jti] = expiresAt
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.RLock()
+	expiresAt, ok := s.tokens[jti]
+	s.mu.RUnlock()
+
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryStore) RevokeUser(_ context.Context, userID int64, before, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Never move an existing cutoff backwards
+	if cur, ok := s.users[userID]; ok && cur.before.After(before) {
+		before = cur.before
+	}
+	s.users[userID] = userCutoff{before: before, expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryStore) IsUserRevoked(_ context.Context, userID int64, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	cut, ok := s.users[userID]
+	s.mu.RUnlock()
+
+	if !ok || time.Now().After(cut.expiresAt) {
+		return false, nil
+	}
+	return issuedAt.Before(cut.before), nil
+}
+
+func (s *MemoryStore) RevokeSession(_ context.Context, sessionID string, expiresAt time.Time) error {
+	if sessionID == "" || time.Now().After(expiresAt) {
+		return nil
+	}
+
+	s.mu.Lock()
+	if cur, ok := s.sessions[sessionID]; !ok || cur.Before(expiresAt) {
+		s.sessions[sessionID] = expiresAt
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) IsSessionRevoked(_ context.Context, sessionID string) (bool, error) {
+	s.mu.RLock()
+	expiresAt, ok := s.sessions[sessionID]
+	s.mu.RUnlock()
+
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+
+		s.mu.Lock()
+		for jti, exp := range s.tokens {
+			if now.After(exp) {
+				delete(s.tokens, jti)
+			}
+		}
+		for id, cut := range s.users {
+			if now.After(cut.expiresAt) {
+				delete(s.users, id)
+			}
+		}
+		for sid, exp := range s.sessions {
+			if now.After(exp) {
+				delete(s.sessions, sid)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
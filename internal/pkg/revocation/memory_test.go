@@ -0,0 +1,71 @@
+package revocation
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSessions(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(time.Hour)
+
+	if err := s.RevokeSession(ctx, "sid-1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	// Already expired tokens need no entry
+	if err := s.RevokeSession(ctx, "sid-2", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		sid  string
+		want bool
+	}{
+		{"sid-1", true},
+		{"sid-2", false},
+		{"sid-3", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		got, err := s.IsSessionRevoked(ctx, tc.sid)
+		if err != nil || got != tc.want {
+			t.Errorf("IsSessionRevoked(%q) = (%v, %v), want %v", tc.sid, got, err, tc.want)
+		}
+	}
+
+	// A shorter expiry never shortens an existing entry
+	_ = s.RevokeSession(ctx, "sid-1", time.Now().Add(time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	if got, _ := s.IsSessionRevoked(ctx, "sid-1"); !got {
+		t.Error("session revocation shortened")
+	}
+}
+
+func TestMemoryStoreUserCutoff(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore(time.Hour)
+	cutoff := time.Now().Truncate(time.Second)
+
+	_ = s.RevokeUser(ctx, 1, cutoff, cutoff.Add(time.Minute))
+	// An earlier cutoff never moves the existing one backwards
+	_ = s.RevokeUser(ctx, 1, cutoff.Add(-time.Hour), cutoff.Add(time.Minute))
+
+	tests := []struct {
+		name     string
+		userID   int64
+		issuedAt time.Time
+		want     bool
+	}{
+		{"issued before the cutoff", 1, cutoff.Add(-time.Second), true},
+		{"issued in the cutoff second", 1, cutoff, false},
+		{"issued after", 1, cutoff.Add(time.Second), false},
+		{"other user", 2, cutoff.Add(-time.Second), false},
+	}
+	for _, tc := range tests {
+		got, err := s.IsUserRevoked(ctx, tc.userID, tc.issuedAt)
+		if err != nil || got != tc.want {
+			t.Errorf("%s: IsUserRevoked = (%v, %v), want %v", tc.name, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// MySQLStore shares revocations between instances through the
+// revoked_tokens, user_token_revocations and revoked_sessions tables.
+type MySQLStore struct {
+	db *sql.DB
+}
+
+// NewMySQLStore creates the store and starts a background purge of expired rows.
+// Expired rows are already ignored by the lookups; the purge only keeps the tables small.
+func NewMySQLStore(db *sql.DB, purgeInterval time.Duration) *MySQLStore {
+	s := &MySQLStore{db: db}
+	go s.purge(purgeInterval)
+	return s
+}
+
+func (s *MySQLStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" || time.Now().After(expiresAt) {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (jti, expires_at)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE expires_at = VALUES(expires_at)
+	`, jti, expiresAt)
+	return err
+}
+
+func (s *MySQLStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var one int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT 1 FROM revoked_tokens
+		WHERE jti = ? AND expires_at > NOW()
+	`, jti).Scan(&one)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *MySQLStore) RevokeUser(ctx context.Context, userID int64, before, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_token_revocations (user_id, revoked_before, expires_at)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			revoked_before = GREATEST(revoked_before, VALUES(revoked_before)),
+			expires_at = GREATEST(expires_at, VALUES(expires_at))
+	`, userID, before, expiresAt)
+	return err
+}
+
+func (s *MySQLStore) IsUserRevoked(ctx context.Context, userID int64, issuedAt time.Time) (bool, error) {
+	var before time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT revoked_before FROM user_token_revocations
+		WHERE user_id = ? AND expires_at > NOW()
+	`, userID).Scan(&before)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return issuedAt.Before(before), nil
+}
+
+func (s *MySQLStore) RevokeSession(ctx context.Context, sessionID string, expiresAt time.Time) error {
+	if sessionID == "" || time.Now().After(expiresAt) {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO revoked_sessions (session_id, expires_at)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE expires_at = GREATEST(expires_at, VALUES(expires_at))
+	`, sessionID, expiresAt)
+	return err
+}
+
+func (s *MySQLStore) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	var one int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT 1 FROM revoked_sessions
+		WHERE session_id = ? AND expires_at > NOW()
+	`, sessionID).Scan(&one)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *MySQLStore) purge(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= NOW()"); err != nil {
+			slog.Error("revocation_purge_failed", "table", "revoked_tokens", "error", err)
+		}
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM user_token_revocations WHERE expires_at <= NOW()"); err != nil {
+			slog.Error("revocation_purge_failed", "table", "user_token_revocations", "error", err)
+		}
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM revoked_sessions WHERE expires_at <= NOW()"); err != nil {
+			slog.Error("revocation_purge_failed", "table", "revoked_sessions", "error", err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,41 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps track of revoked access tokens until they would have expired anyway.
+//
+// Single tokens are revoked by their jti; RevokeUser revokes every token of a
+// user issued before a given instant (used by "logout everywhere").
+// RevokeSession revokes every token carrying a session ID (sid); it has no
+// cutoff because an ended session is never issued new tokens.
+type Store interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUser(ctx context.Context, userID int64, before, expiresAt time.Time) error
+	IsUserRevoked(ctx context.Context, userID int64, issuedAt time.Time) (bool, error)
+	RevokeSession(ctx context.Context, sessionID string, expiresAt time.Time) error
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+var (
+	defaultStore Store = NewMemoryStore(time.Minute)
+	defaultMu    sync.RWMutex
+)
+
+// Default returns the store consulted by the JWT middleware
+func Default() Store {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStore
+}
+
+// SetDefault replaces the store consulted by the JWT middleware
+func SetDefault(s Store) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStore = s
+}
@@ -0,0 +1,17 @@
+package signing
+
+import "context"
+
+// ClientStore returns the shared secret of a signing client.
+// ok is false for unknown clients.
+type ClientStore interface {
+	Secret(ctx context.Context, clientID string) (secret string, ok bool, err error)
+}
+
+// StaticClientStore serves the clients configured in SIGNING_CLIENTS
+type StaticClientStore map[string]string
+
+func (s StaticClientStore) Secret(_ context.Context, clientID string) (string, bool, error) {
+	secret, ok := s[clientID]
+	return secret, ok, nil
+}
@@ -0,0 +1,66 @@
+package signing
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type nonceEntry struct {
+	key       string
+	expiresAt time.Time
+}
+
+// NonceCache remembers nonces until the timestamp window they were used in
+// has passed. It holds at most maxSize entries; when full the oldest entry is
+// dropped. Only nonces of correctly signed requests are stored, so filling it
+// requires a valid client secret.
+type NonceCache struct {
+	mu      sync.Mutex
+	maxSize int
+	order   *list.List // oldest first; entries share one TTL so this is also expiry order
+	index   map[string]*list.Element
+}
+
+func NewNonceCache(maxSize int) *NonceCache {
+	return &NonceCache{
+		maxSize: maxSize,
+		order:   list.New(),
+		index:   make(map[string]*list.Element),
+	}
+}
+
+// Use records key and reports whether it was fresh. A false result means the
+// nonce was already used, i.e. the request is a replay.
+func (c *NonceCache) Use(key string, ttl time.Duration) bool {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictExpired(now)
+
+	if _, seen := c.index[key]; seen {
+		return false
+	}
+
+	for c.order.Len() >= c.maxSize {
+		c.remove(c.order.Front())
+	}
+	c.index[key] = c.order.PushBack(&nonceEntry{key: key, expiresAt: now.Add(ttl)})
+	return true
+}
+
+func (c *NonceCache) evictExpired(now time.Time) {
+	for e := c.order.Front(); e != nil; e = c.order.Front() {
+		if now.Before(e.Value.(*nonceEntry).expiresAt) {
+			return
+		}
+		c.remove(e)
+	}
+}
+
+func (c *NonceCache) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.index, e.Value.(*nonceEntry).key)
+}
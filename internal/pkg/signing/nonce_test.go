@@ -0,0 +1,61 @@
+package signing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNonceCacheReplay(t *testing.T) {
+	c := NewNonceCache(10)
+
+	if !c.Use("app:n1", time.Minute) {
+		t.Fatal("fresh nonce refused")
+	}
+	if c.Use("app:n1", time.Minute) {
+		t.Fatal("replayed nonce accepted")
+	}
+	if !c.Use("other:n1", time.Minute) {
+		t.Fatal("same nonce of another client refused")
+	}
+}
+
+func TestNonceCacheExpiry(t *testing.T) {
+	c := NewNonceCache(10)
+
+	c.Use("app:old", 0) // expires at once
+	c.Use("app:kept", time.Minute)
+
+	if !c.Use("app:old", time.Minute) {
+		t.Error("expired nonce still remembered")
+	}
+	if c.Use("app:kept", time.Minute) {
+		t.Error("nonce forgotten before it expired")
+	}
+	if n := c.order.Len(); n != len(c.index) || n != 2 {
+		t.Errorf("entries = %d (index %d), want 2", n, len(c.index))
+	}
+}
+
+func TestNonceCacheEviction(t *testing.T) {
+	const size = 3
+	c := NewNonceCache(size)
+
+	for i := range size + 2 {
+		if !c.Use(fmt.Sprintf("app:n%d", i), time.Minute) {
+			t.Fatalf("fresh nonce %d refused", i)
+		}
+	}
+
+	if n := c.order.Len(); n != size || len(c.index) != size {
+		t.Fatalf("entries = %d (index %d), want %d", n, len(c.index), size)
+	}
+
+	// The oldest entries made room for the newest
+	for i, want := range []bool{false, false, true, true, true} {
+		key := fmt.Sprintf("app:n%d", i)
+		if _, seen := c.index[key]; seen != want {
+			t.Errorf("%s remembered = %v, want %v", key, seen, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Request headers of the signing scheme
+const (
+	HeaderClientID  = "X-Client-ID"
+	HeaderTimestamp = "X-Timestamp" // unix seconds
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature" // hex HMAC-SHA256 of StringToSign
+)
+
+// StringToSign builds the canonical form a client signs:
+//
+//	METHOD\nPATH\nCANONICAL_QUERY\nHEX(SHA256(BODY))\nTIMESTAMP\nNONCE
+//
+// PATH is the escaped request path as sent (including /api/v1). The query is
+// sorted by key then value and re-encoded, so parameter order does not matter.
+func StringToSign(method, path, rawQuery string, body []byte, timestamp, nonce string) string {
+	sum := sha256.Sum256(body)
+
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		canonicalQuery(rawQuery),
+		hex.EncodeToString(sum[:]),
+		timestamp,
+		nonce,
+	}, "\n")
+}
+
+// Sign returns the hex HMAC-SHA256 of stringToSign with the client secret
+func Sign(secret, stringToSign string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func canonicalQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), values[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,41 @@
+package signing
+
+import "testing"
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "empty", raw: "", want: ""},
+		{name: "sorted by key", raw: "b=2&a=1&c=3", want: "a=1&b=2&c=3"},
+		{name: "repeated key sorted by value", raw: "tag=z&tag=a&tag=m", want: "tag=a&tag=m&tag=z"},
+		{name: "key order before value order", raw: "b=1&a=2&a=1", want: "a=1&a=2&b=1"},
+		{name: "re-encoded", raw: "q=a+b&name=J%C3%BCrgen&x=%2F", want: "name=J%C3%BCrgen&q=a+b&x=%2F"},
+		{name: "spaces encoded one way", raw: "q=a%20b", want: "q=a+b"},
+		{name: "empty value", raw: "flag=&a=1", want: "a=1&flag="},
+		{name: "unparsable kept as sent", raw: "a=%zz", want: "a=%zz"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := canonicalQuery(tc.raw); got != tc.want {
+				t.Errorf("canonicalQuery(%q) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	got := StringToSign("post", "/api/v1/user/me", "b=2&a=1", []byte(`{"x":1}`), "1700000000", "n-0123456789abcdef")
+	want := "POST\n" +
+		"/api/v1/user/me\n" +
+		"a=1&b=2\n" +
+		"5041bf1f713df204784353e82f6a4a535931cb64f1f4b4a5aeaffcb720918b22\n" +
+		"1700000000\n" +
+		"n-0123456789abcdef"
+	if got != want {
+		t.Errorf("StringToSign = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package signing
+
+import (
+	"context"
+	"crypto/hmac"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// Verifier checks signed requests against a ClientStore
+type Verifier struct {
+	clients ClientStore
+	nonces  *NonceCache
+	maxSkew time.Duration
+}
+
+func NewVerifier(clients ClientStore, nonces *NonceCache, maxSkew time.Duration) *Verifier {
+	return &Verifier{clients: clients, nonces: nonces, maxSkew: maxSkew}
+}
+
+// Verify checks the signature headers of r against body and returns the
+// client ID. The checks run cheapest first; the nonce is only recorded once
+// the signature is known to be valid.
+func (v *Verifier) Verify(ctx context.Context, r *http.Request, path string, body []byte) (string, error) {
+	clientID := r.Header.Get(HeaderClientID)
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature := r.Header.Get(HeaderSignature)
+
+	if clientID == "" || timestamp == "" || nonce == "" || signature == "" {
+		return "", apperr.ErrSignatureMissing
+	}
+	if len(nonce) < 16 || len(nonce) > 128 {
+		return "", apperr.ErrInvalidSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", apperr.ErrInvalidSignature
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return "", apperr.ErrSignatureExpired
+	}
+
+	secret, ok, err := v.clients.Secret(ctx, clientID)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", apperr.ErrUnknownClient
+	}
+
+	expected := Sign(secret, StringToSign(r.Method, path, r.URL.RawQuery, body, timestamp, nonce))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", apperr.ErrInvalidSignature
+	}
+
+	// A timestamp is accepted for maxSkew either side of now, so the nonce
+	// must be remembered for the whole 2*maxSkew window
+	if !v.nonces.Use(clientID+":"+nonce, 2*v.maxSkew) {
+		return "", apperr.ErrSignatureReplayed
+	}
+
+	return clientID, nil
+}
+
+var (
+	defaultVerifier *Verifier
+	defaultMu       sync.Mutex
+)
+
+// Default returns the verifier used by the signature middleware. Unless
+// SetDefault was called it serves the clients configured in SIGNING_CLIENTS.
+func Default() *Verifier {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultVerifier == nil {
+		cfg := config.Get().ClientAuth
+		defaultVerifier = NewVerifier(
+			StaticClientStore(cfg.SigningClients),
+			NewNonceCache(cfg.NonceCacheSize),
+			cfg.SigningMaxSkew,
+		)
+	}
+	return defaultVerifier
+}
+
+// SetDefault replaces the verifier used by the signature middleware
+func SetDefault(v *Verifier) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultVerifier = v
+}
@@ -0,0 +1,158 @@
+package signing
+
+import (
+	"context"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+)
+
+const (
+	testSecret  = "s3cret"
+	testMaxSkew = 5 * time.Minute
+	testPath    = "/api/v1/user/me"
+	testNonce   = "0123456789abcdef"
+)
+
+type signedRequest struct {
+	method    string
+	path      string // path the client signed
+	query     string // query the client signed
+	body      string // body the client signed
+	clientID  string
+	secret    string
+	timestamp string
+	nonce     string
+}
+
+func validRequest() signedRequest {
+	return signedRequest{
+		method:    "POST",
+		path:      testPath,
+		query:     "b=2&a=1",
+		body:      `{"name":"alice"}`,
+		clientID:  "app",
+		secret:    testSecret,
+		timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		nonce:     testNonce,
+	}
+}
+
+func (s signedRequest) signature() string {
+	return Sign(s.secret, StringToSign(s.method, s.path, s.query, []byte(s.body), s.timestamp, s.nonce))
+}
+
+func newTestVerifier() *Verifier {
+	return NewVerifier(StaticClientStore{"app": testSecret}, NewNonceCache(100), testMaxSkew)
+}
+
+func TestVerifierVerify(t *testing.T) {
+	at := func(d time.Duration) string { return strconv.FormatInt(time.Now().Add(d).Unix(), 10) }
+
+	tests := []struct {
+		name    string
+		signed  func(s *signedRequest) // changes what the client signed
+		sent    func(s *signedRequest) // changes what is sent after signing
+		headers func(h map[string]string)
+		wantErr error
+	}{
+		{name: "valid"},
+		{name: "query order does not matter", sent: func(s *signedRequest) { s.query = "a=1&b=2" }},
+		{name: "timestamp inside the skew", signed: func(s *signedRequest) { s.timestamp = at(-testMaxSkew + 5*time.Second) }},
+		{name: "future timestamp inside the skew", signed: func(s *signedRequest) { s.timestamp = at(testMaxSkew - 5*time.Second) }},
+		{name: "shortest nonce", signed: func(s *signedRequest) { s.nonce = strings.Repeat("n", 16) }},
+		{name: "longest nonce", signed: func(s *signedRequest) { s.nonce = strings.Repeat("n", 128) }},
+
+		{name: "no client id", headers: func(h map[string]string) { delete(h, HeaderClientID) }, wantErr: apperr.ErrSignatureMissing},
+		{name: "no timestamp", headers: func(h map[string]string) { delete(h, HeaderTimestamp) }, wantErr: apperr.ErrSignatureMissing},
+		{name: "no nonce", headers: func(h map[string]string) { delete(h, HeaderNonce) }, wantErr: apperr.ErrSignatureMissing},
+		{name: "no signature", headers: func(h map[string]string) { delete(h, HeaderSignature) }, wantErr: apperr.ErrSignatureMissing},
+
+		{name: "nonce too short", signed: func(s *signedRequest) { s.nonce = strings.Repeat("n", 15) }, wantErr: apperr.ErrInvalidSignature},
+		{name: "nonce too long", signed: func(s *signedRequest) { s.nonce = strings.Repeat("n", 129) }, wantErr: apperr.ErrInvalidSignature},
+		{name: "timestamp not a number", signed: func(s *signedRequest) { s.timestamp = "yesterday" }, wantErr: apperr.ErrInvalidSignature},
+		{name: "timestamp too old", signed: func(s *signedRequest) { s.timestamp = at(-testMaxSkew - 5*time.Second) }, wantErr: apperr.ErrSignatureExpired},
+		{name: "timestamp too far ahead", signed: func(s *signedRequest) { s.timestamp = at(testMaxSkew + 5*time.Second) }, wantErr: apperr.ErrSignatureExpired},
+		{name: "unknown client", headers: func(h map[string]string) { h[HeaderClientID] = "other" }, wantErr: apperr.ErrUnknownClient},
+
+		{name: "wrong secret", signed: func(s *signedRequest) { s.secret = "guessed" }, wantErr: apperr.ErrInvalidSignature},
+		{name: "tampered body", sent: func(s *signedRequest) { s.body = `{"name":"mallory"}` }, wantErr: apperr.ErrInvalidSignature},
+		{name: "tampered path", sent: func(s *signedRequest) { s.path = "/api/v1/admin/users" }, wantErr: apperr.ErrInvalidSignature},
+		{name: "tampered query", sent: func(s *signedRequest) { s.query = "a=1&b=3" }, wantErr: apperr.ErrInvalidSignature},
+		{name: "tampered method", sent: func(s *signedRequest) { s.method = "DELETE" }, wantErr: apperr.ErrInvalidSignature},
+		{name: "tampered timestamp", headers: func(h map[string]string) { h[HeaderTimestamp] = at(-10 * time.Second) }, wantErr: apperr.ErrInvalidSignature},
+		{name: "tampered nonce", headers: func(h map[string]string) { h[HeaderNonce] = "fedcba9876543210" }, wantErr: apperr.ErrInvalidSignature},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := validRequest()
+			if tc.signed != nil {
+				tc.signed(&s)
+			}
+			signature := s.signature()
+			if tc.sent != nil {
+				tc.sent(&s)
+			}
+
+			headers := map[string]string{
+				HeaderClientID:  s.clientID,
+				HeaderTimestamp: s.timestamp,
+				HeaderNonce:     s.nonce,
+				HeaderSignature: signature,
+			}
+			if tc.headers != nil {
+				tc.headers(headers)
+			}
+
+			r := httptest.NewRequest(s.method, s.path+"?"+s.query, strings.NewReader(s.body))
+			for k, v := range headers {
+				r.Header.Set(k, v)
+			}
+
+			clientID, err := newTestVerifier().Verify(context.Background(), r, s.path, []byte(s.body))
+			if err != tc.wantErr {
+				t.Fatalf("Verify error = %v, want %v", err, tc.wantErr)
+			}
+			if err == nil && clientID != "app" {
+				t.Errorf("client = %q, want app", clientID)
+			}
+		})
+	}
+}
+
+func TestVerifierReplay(t *testing.T) {
+	v := newTestVerifier()
+
+	send := func(s signedRequest, signature string) error {
+		r := httptest.NewRequest(s.method, s.path+"?"+s.query, strings.NewReader(s.body))
+		r.Header.Set(HeaderClientID, s.clientID)
+		r.Header.Set(HeaderTimestamp, s.timestamp)
+		r.Header.Set(HeaderNonce, s.nonce)
+		r.Header.Set(HeaderSignature, signature)
+		_, err := v.Verify(context.Background(), r, s.path, []byte(s.body))
+		return err
+	}
+
+	s := validRequest()
+
+	// A forged request must not use up the nonce of the genuine one
+	if err := send(s, strings.Repeat("0", 64)); err != apperr.ErrInvalidSignature {
+		t.Fatalf("forged: error = %v, want ErrInvalidSignature", err)
+	}
+	if err := send(s, s.signature()); err != nil {
+		t.Fatalf("first: %v", err)
+	}
+	if err := send(s, s.signature()); err != apperr.ErrSignatureReplayed {
+		t.Fatalf("replay: error = %v, want ErrSignatureReplayed", err)
+	}
+
+	s.nonce = "another-nonce-0001"
+	if err := send(s, s.signature()); err != nil {
+		t.Fatalf("new nonce: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// HashToken hashes a one-time code or token for storage. It is keyed with
+// JWT_SECRET so short numeric OTPs cannot be brute-forced from a DB dump alone.
+func HashToken(token string) string {
+	mac := hmac.New(sha256.New, []byte(config.Get().JWT.Secret))
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CompareTokenHash checks a plain token against a stored HashToken value in constant time
+func CompareTokenHash(hash, token string) bool {
+	return hmac.Equal([]byte(hash), []byte(HashToken(token)))
+}
+
+// HashSecret hashes a long random secret (API key, client secret) for storage.
+// Unlike HashToken it is not keyed, so stored secrets survive a JWT_SECRET rotation;
+// the secrets are high-entropy, so a plain SHA-256 is enough.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareSecretHash checks a plain secret against a stored HashSecret value in constant time
+func CompareSecretHash(hash, secret string) bool {
+	return hmac.Equal([]byte(hash), []byte(HashSecret(secret)))
+}
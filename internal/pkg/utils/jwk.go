@@ -0,0 +1,388 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+// Supported JWT_ALGORITHM values
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// JWK is the public part of a verification key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type verificationKey struct {
+	alg    string
+	public crypto.PublicKey
+	jwk    JWK
+}
+
+// KeySet holds the key tokens are signed with and every key they may be
+// verified with. Asymmetric keys are identified by their RFC 7638 thumbprint,
+// which is sent as the kid header, so the same PEM always gets the same kid.
+type KeySet struct {
+	alg        string
+	kid        string
+	signingKey any // []byte for HS256, otherwise a crypto.Signer
+
+	secret      []byte
+	acceptHMAC  bool
+	verifyKeys  []*verificationKey // in config order, signing key first
+	verifyByKid map[string]*verificationKey
+}
+
+// NewKeySet loads the signing key and any extra verification keys from the
+// PEM files named in cfg.
+func NewKeySet(cfg config.JWTConfig) (*KeySet, error) {
+	ks := &KeySet{
+		alg:         cfg.Algorithm,
+		secret:      []byte(cfg.Secret),
+		acceptHMAC:  cfg.Algorithm == AlgHS256 || cfg.AcceptHS256,
+		verifyByKid: make(map[string]*verificationKey),
+	}
+
+	switch ks.alg {
+	case AlgHS256:
+		ks.signingKey = ks.secret
+	case AlgRS256, AlgES256, AlgEdDSA:
+		if cfg.PrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", ks.alg)
+		}
+		signer, err := loadPrivateKey(cfg.PrivateKeyFile, ks.alg)
+		if err != nil {
+			return nil, err
+		}
+		vk, err := newVerificationKey(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		ks.signingKey = signer
+		ks.kid = vk.jwk.Kid
+		ks.addVerificationKey(vk)
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", ks.alg)
+	}
+
+	// Previous public keys stay valid until the tokens they signed expire
+	for _, file := range cfg.PublicKeyFiles {
+		pub, err := loadPublicKey(file)
+		if err != nil {
+			return nil, err
+		}
+		vk, err := newVerificationKey(pub)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		ks.addVerificationKey(vk)
+	}
+
+	return ks, nil
+}
+
+func (ks *KeySet) addVerificationKey(vk *verificationKey) {
+	if _, exists := ks.verifyByKid[vk.jwk.Kid]; exists {
+		return
+	}
+	ks.verifyByKid[vk.jwk.Kid] = vk
+	ks.verifyKeys = append(ks.verifyKeys, vk)
+}
+
+// JWKS returns the public verification keys. It is empty when only HS256 is in use.
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, vk := range ks.verifyKeys {
+		set.Keys = append(set.Keys, vk.jwk)
+	}
+	return set
+}
+
+// PublicKey decodes a JWK published by someone else (an identity provider)
+// and returns it with the algorithm we accept it for. RSA, P-256 and Ed25519
+// keys are supported, matching what we can sign with ourselves.
+func (k JWK) PublicKey() (crypto.PublicKey, string, error) {
+	b64 := base64.RawURLEncoding.DecodeString
+
+	var pub crypto.PublicKey
+	switch k.Kty {
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return nil, "", err
+		}
+		e, err := b64(k.E)
+		if err != nil {
+			return nil, "", err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exp.IsInt64() || exp.Int64() < 3 {
+			return nil, "", errors.New("RSA key too small or malformed")
+		}
+		pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, "", fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, "", err
+		}
+		y, err := b64(k.Y)
+		if err != nil {
+			return nil, "", err
+		}
+		// Round-trip through the uncompressed point encoding so that
+		// points not on the curve are rejected
+		point := append(append([]byte{4}, leftPad(x, 32)...), leftPad(y, 32)...)
+		ecKey, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+		if err != nil {
+			return nil, "", err
+		}
+		pub = ecKey
+	case "OKP":
+		x, err := b64(k.X)
+		if err != nil {
+			return nil, "", err
+		}
+		if k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, "", errors.New("unsupported OKP key")
+		}
+		pub = ed25519.PublicKey(x)
+	default:
+		return nil, "", fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	return pub, algorithmFor(pub), nil
+}
+
+func leftPad(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.alg), claims)
+	if ks.kid != "" {
+		token.Header["kid"] = ks.kid
+	}
+	return token.SignedString(ks.signingKey)
+}
+
+// verificationKey is the jwt.Keyfunc: HMAC tokens use the shared secret (if
+// still accepted), others are looked up by kid and must use that key's algorithm.
+func (ks *KeySet) verificationKey(t *jwt.Token) (any, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		if !ks.acceptHMAC || t.Method.Alg() != AlgHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return ks.secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	vk, ok := ks.verifyByKid[kid]
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	if t.Method.Alg() != vk.alg {
+		return nil, errors.New("unexpected signing method")
+	}
+	return vk.public, nil
+}
+
+var (
+	jwtKeys     *KeySet
+	jwtKeysErr  error
+	jwtKeysOnce sync.Once
+)
+
+// LoadJWTKeys loads the key set from config once. main calls it at startup so
+// a bad key file stops the process before it serves traffic.
+func LoadJWTKeys() (*KeySet, error) {
+	jwtKeysOnce.Do(func() {
+		jwtKeys, jwtKeysErr = NewKeySet(config.Get().JWT)
+	})
+	return jwtKeys, jwtKeysErr
+}
+
+func readPEM(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", file)
+	}
+	return block, nil
+}
+
+func loadPrivateKey(file, alg string) (crypto.Signer, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported private key type %T", file, key)
+	}
+	if got := algorithmFor(signer.Public()); got != alg {
+		return nil, fmt.Errorf("%s: key is not usable with %s", file, alg)
+	}
+	return signer, nil
+}
+
+func loadPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return cert.PublicKey, nil
+	default:
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return pub, nil
+	}
+}
+
+// algorithmFor maps a public key to the JWS algorithm we use it with
+func algorithmFor(pub crypto.PublicKey) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return AlgRS256
+	case *ecdsa.PublicKey:
+		if k.Curve == elliptic.P256() {
+			return AlgES256
+		}
+	case ed25519.PublicKey:
+		return AlgEdDSA
+	}
+	return ""
+}
+
+func newVerificationKey(pub crypto.PublicKey) (*verificationKey, error) {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var jwk JWK
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{Kty: "RSA", N: b64(k.N.Bytes()), E: b64(big.NewInt(int64(k.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 EC keys are supported")
+		}
+		x, y := make([]byte, 32), make([]byte, 32)
+		k.X.FillBytes(x)
+		k.Y.FillBytes(y)
+		jwk = JWK{Kty: "EC", Crv: "P-256", X: b64(x), Y: b64(y)}
+	case ed25519.PublicKey:
+		jwk = JWK{Kty: "OKP", Crv: "Ed25519", X: b64(k)}
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	kid, err := thumbprint(jwk)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = kid
+	jwk.Use = "sig"
+	jwk.Alg = algorithmFor(pub)
+
+	return &verificationKey{alg: jwk.Alg, public: pub, jwk: jwk}, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint: the SHA-256 of the required
+// members in lexicographic order (struct field order below matches it).
+func thumbprint(jwk JWK) (string, error) {
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -2,10 +2,12 @@ package utils
 
 import (
 	"errors"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
 )
 
 type Claims struct {
@@ -14,82 +16,237 @@ type Claims struct {
 	Role   string `json:"role,omitempty"`
 	UUID   string `json:"uuid,omitempty"`
 	Type   string `json:"type"` // "access" or "refresh"
-	jwt.RegisteredClaims
+
+	// SessionID ties access and refresh tokens to the login they came from
+	SessionID string `json:"sid,omitempty"`
+
+	// ClientID and Scope are set on OAuth (delegated) tokens only. Scope is
+	// space separated as in RFC 6749.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+
+	// Actor is set when an admin is impersonating the user (RFC 8693 act)
+	Actor *Actor `json:"act,omitempty"`
+
+	jwt.RegisteredClaims // jti (ID) is set on every issued token
+}
+
+// Actor identifies who is really behind an impersonation token
+type Actor struct {
+	Subject string `json:"sub"` // admin UUID
+	UserID  int64  `json:"id"`
+	Email   string `json:"email,omitempty"`
+}
+
+// TokenPair holds a freshly signed access/refresh pair along with the
+// refresh token metadata needed to persist it for rotation.
+type TokenPair struct {
+	AccessToken      string
+	AccessID         string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshID        string
+	RefreshExpiresAt time.Time
 }
 
 type Service struct {
-	secret     []byte
+	keys       *KeySet
 	accessTTL  time.Duration
 	refreshTTL time.Duration
+	mfaTTL     time.Duration
+	actTTL     time.Duration
+	issuer     string
+	audience   string
+	leeway     time.Duration
 }
 
 func NewJWT() *Service {
 	cfg := config.Get()
 
+	keys, err := LoadJWTKeys()
+	if err != nil {
+		log.Fatalf("jwt keys: %v", err)
+	}
+
 	// TTL = Time To Live (Expiration Duration)
 	return &Service{
-		secret:     []byte(cfg.JWT.Secret),
+		keys:       keys,
 		accessTTL:  cfg.JWT.AccessExpiration,  // Taken from JWT_ACCESS_EXPIRES_IN (e.g., "1h")
 		refreshTTL: cfg.JWT.RefreshExpiration, // Taken from JWT_REFRESH_EXPIRES_IN (e.g., "168h")
+		mfaTTL:     cfg.JWT.MFAExpiration,     // Taken from JWT_MFA_EXPIRES_IN (e.g., "5m")
+		actTTL:     cfg.JWT.ImpersonationTTL,  // Taken from JWT_IMPERSONATION_EXPIRES_IN (e.g., "15m")
+		issuer:     cfg.JWT.Issuer,
+		audience:   cfg.JWT.Audience,
+		leeway:     cfg.JWT.Leeway,
 	}
 }
 
+// AccessTTL is how long an access token issued by this service stays valid
+func (s *Service) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
 func (s *Service) Generate(claims Claims) (accessToken string, refreshToken string, err error) {
+	pair, err := s.GeneratePair(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return pair.AccessToken, pair.RefreshToken, nil
+}
+
+// GeneratePair signs an access and a refresh token for the given claims.
+// Every refresh token gets a unique ID (jti) so it can be tracked and rotated.
+func (s *Service) GeneratePair(claims Claims) (*TokenPair, error) {
 	// now is the current server time used as a base for tokens
 	now := time.Now()
 
 	// --------------------
 	// Access Token (short)
 	// --------------------
+	accessID := UUID()
+	accessExpiresAt := now.Add(s.accessTTL)
 	accessClaims := Claims{
-		UserID: claims.UserID,
-		Email:  claims.Email,
-		Role:   claims.Role,
-		UUID:   claims.UUID,
-		Type:   "access",
-		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
-		},
+		UserID:           claims.UserID,
+		Email:            claims.Email,
+		Role:             claims.Role,
+		UUID:             claims.UUID,
+		Type:             constants.TokenTypeAccess,
+		SessionID:        claims.SessionID,
+		ClientID:         claims.ClientID,
+		Scope:            claims.Scope,
+		RegisteredClaims: s.registered(accessID, claims.UUID, now, accessExpiresAt),
 	}
 
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err = at.SignedString(s.secret)
+	accessToken, err := s.keys.sign(accessClaims)
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	// --------------------
 	// Refresh Token (long)
 	// --------------------
+	refreshID := UUID()
+	refreshExpiresAt := now.Add(s.refreshTTL)
 	refreshClaims := Claims{
-		UserID: claims.UserID,
-		UUID:   claims.UUID,
-		Type:   "refresh",
-		RegisteredClaims: jwt.RegisteredClaims{
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.refreshTTL)),
-		},
+		UserID:           claims.UserID,
+		UUID:             claims.UUID,
+		Type:             constants.TokenTypeRefresh,
+		SessionID:        claims.SessionID,
+		ClientID:         claims.ClientID,
+		Scope:            claims.Scope,
+		RegisteredClaims: s.registered(refreshID, claims.UUID, now, refreshExpiresAt),
+	}
+
+	refreshToken, err := s.keys.sign(refreshClaims)
+	if err != nil {
+		return nil, err
 	}
 
-	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err = rt.SignedString(s.secret)
-	return
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessID:         accessID,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshID:        refreshID,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
 }
 
-func (s *Service) Parse(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (any, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return s.secret, nil
+// GenerateClientAccess signs an access token for an OAuth client acting on
+// its own behalf (client_credentials grant). The subject is the client ID and
+// no user is attached.
+func (s *Service) GenerateClientAccess(clientID, scope string) (token string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(s.accessTTL)
+
+	token, err = s.keys.sign(Claims{
+		Type:             constants.TokenTypeAccess,
+		ClientID:         clientID,
+		Scope:            scope,
+		RegisteredClaims: s.registered(UUID(), clientID, now, expiresAt),
+	})
+	return token, expiresAt, err
+}
+
+// GenerateImpersonation signs a short-lived access token for the target user
+// on behalf of actor. No refresh token or session comes with it, so it cannot
+// be extended. The token ID is returned for the audit trail.
+func (s *Service) GenerateImpersonation(claims Claims, actor Actor) (token, tokenID string, expiresAt time.Time, err error) {
+	now := time.Now()
+	tokenID = UUID()
+	expiresAt = now.Add(s.actTTL)
+
+	token, err = s.keys.sign(Claims{
+		UserID:           claims.UserID,
+		Email:            claims.Email,
+		Role:             claims.Role,
+		UUID:             claims.UUID,
+		Type:             constants.TokenTypeAccess,
+		Actor:            &actor,
+		RegisteredClaims: s.registered(tokenID, claims.UUID, now, expiresAt),
 	})
+	return token, tokenID, expiresAt, err
+}
+
+// GenerateMFAPending signs the short-lived token handed out after a correct
+// password when the user still has to provide a second factor. It carries no
+// email or role and is rejected everywhere except /auth/2fa/verify.
+func (s *Service) GenerateMFAPending(claims Claims) (string, error) {
+	now := time.Now()
+
+	pendingClaims := Claims{
+		UserID:           claims.UserID,
+		UUID:             claims.UUID,
+		Type:             constants.TokenTypeMFAPending,
+		RegisteredClaims: s.registered(UUID(), claims.UUID, now, now.Add(s.mfaTTL)),
+	}
+
+	return s.keys.sign(pendingClaims)
+}
+
+// registered builds the standard claims shared by every token we issue.
+// The subject is the user UUID.
+func (s *Service) registered(id, subject string, now, expiresAt time.Time) jwt.RegisteredClaims {
+	rc := jwt.RegisteredClaims{
+		ID:        id,
+		Issuer:    s.issuer,
+		Subject:   subject,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	if s.audience != "" {
+		rc.Audience = jwt.ClaimStrings{s.audience}
+	}
+	return rc
+}
+
+// Parse verifies the signature and the registered claims: exp is required,
+// nbf/iat are checked with the configured leeway and, when configured, iss
+// and aud must match ours. sub must be present and agree with the uuid claim.
+func (s *Service) Parse(tokenString string) (*Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(s.leeway),
+	}
+	if s.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(s.issuer))
+	}
+	if s.audience != "" {
+		opts = append(opts, jwt.WithAudience(s.audience))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.keys.verificationKey, opts...)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims.Subject == "" || (claims.UUID != "" && claims.Subject != claims.UUID) {
+			return nil, errors.New("invalid subject")
+		}
 		return claims, nil
 	}
 
@@ -1,20 +1,183 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
-const defaultCost = bcrypt.DefaultCost
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
 
-// HashPassword hashes plain password
-func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword(
-		[]byte(password),
-		defaultCost,
-	)
+// PasswordHasher turns passwords into self-describing hash strings.
+// ComparePassword reads the algorithm and parameters back from the hash, so
+// the configured hasher can change without locking anyone out.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether a stored hash was made with another
+	// algorithm or with parameters other than the current ones
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idHasher produces PHC strings:
+// $argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+var phcEncoding = base64.RawStdEncoding
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, argon2KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Threads, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return p.memory != h.Memory || p.time != h.Time || p.threads != h.Threads ||
+		len(p.salt) != argon2SaltLen || len(p.key) != argon2KeyLen
+}
+
+// BcryptHasher produces modular crypt strings ($2a$<cost>$...), the form
+// the PHC format adopts for bcrypt. Passwords over 72 bytes are refused
+// instead of being silently truncated.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > 72 {
+		return "", apperr.ErrPasswordTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	return string(hash), err
 }
 
-// ComparePassword compares hash & plain password
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+var (
+	defaultHasher PasswordHasher
+	hasherMu      sync.Mutex
+)
+
+// DefaultPasswordHasher returns the hasher used for new passwords. Unless
+// SetPasswordHasher was called it is built from the PASSWORD_* settings.
+func DefaultPasswordHasher() PasswordHasher {
+	hasherMu.Lock()
+	defer hasherMu.Unlock()
+
+	if defaultHasher == nil {
+		cfg := config.Get().Password
+		switch cfg.Hasher {
+		case config.PasswordHasherBcrypt:
+			defaultHasher = BcryptHasher{Cost: cfg.BcryptCost}
+		default:
+			defaultHasher = Argon2idHasher{Memory: cfg.Argon2Memory, Time: cfg.Argon2Time, Threads: cfg.Argon2Threads}
+		}
+	}
+	return defaultHasher
+}
+
+// SetPasswordHasher replaces the hasher used for new passwords
+func SetPasswordHasher(h PasswordHasher) {
+	hasherMu.Lock()
+	defer hasherMu.Unlock()
+	defaultHasher = h
+}
+
+// HashPassword hashes plain password with the configured hasher
+func HashPassword(password string) (string, error) {
+	return DefaultPasswordHasher().Hash(password)
+}
+
+// PasswordNeedsRehash reports whether a stored hash should be replaced by
+// one from the configured hasher
+func PasswordNeedsRehash(hash string) bool {
+	return DefaultPasswordHasher().NeedsRehash(hash)
+}
+
+// ComparePassword compares hash & plain password. The algorithm and its
+// parameters are read from the hash, so every supported format verifies.
 func ComparePassword(hash, password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		p, err := parseArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		key := argon2.IDKey([]byte(password), p.salt, p.time, p.memory, p.threads, uint32(len(p.key)))
+		return subtle.ConstantTimeCompare(key, p.key) == 1
+	case strings.HasPrefix(hash, "$2"):
+		// bcrypt would only look at the first 72 bytes
+		if len(password) > 72 {
+			return false
+		}
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	return false
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2id(hash string) (*argon2Params, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2 version")
+	}
+
+	var p argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return nil, err
+	}
+	if p.time == 0 || p.threads == 0 {
+		return nil, errors.New("invalid argon2 parameters")
+	}
+
+	var err error
+	if p.salt, err = phcEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+	if p.key, err = phcEncoding.DecodeString(parts[5]); err != nil {
+		return nil, err
+	}
+	if len(p.key) == 0 {
+		return nil, errors.New("empty argon2 key")
+	}
+	return &p, nil
 }
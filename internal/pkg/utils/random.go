@@ -29,3 +29,17 @@ func OTP(length int) (string, error) {
 
 	return string(result), nil
 }
+
+// RandomString generates a secure random string of length n drawn from alphabet
+func RandomString(n int, alphabet string) (string, error) {
+	result := make([]byte, n)
+	for i := range result {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		result[i] = alphabet[idx.Int64()]
+	}
+
+	return string(result), nil
+}
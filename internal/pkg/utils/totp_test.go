@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of RFC 6238 Appendix B, "12345678901234567890"
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestVerifyTOTPVectors(t *testing.T) {
+	// RFC 6238 Appendix B lists 8 digit codes; a 6 digit code is the last six
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range tests {
+		step, ok := VerifyTOTP(rfc6238Secret, tc.code, time.Unix(tc.unix, 0), 0)
+		if !ok {
+			t.Errorf("T=%d: code %s rejected", tc.unix, tc.code)
+			continue
+		}
+		if step != tc.unix/totpPeriod {
+			t.Errorf("T=%d: step = %d, want %d", tc.unix, step, tc.unix/totpPeriod)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0) // step 37037037, code 050471
+	const step = 1111111111 / totpPeriod
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       time.Time
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", secret: rfc6238Secret, code: "050471", at: at, skew: 1, wantStep: step, wantOK: true},
+		{name: "lower case secret", secret: strings.ToLower(rfc6238Secret), code: "050471", at: at, skew: 1, wantStep: step, wantOK: true},
+		{name: "one step late within skew", secret: rfc6238Secret, code: "050471", at: at.Add(totpPeriod * time.Second), skew: 1, wantStep: step, wantOK: true},
+		{name: "one step early within skew", secret: rfc6238Secret, code: "050471", at: at.Add(-totpPeriod * time.Second), skew: 1, wantStep: step, wantOK: true},
+		{name: "two steps late", secret: rfc6238Secret, code: "050471", at: at.Add(2 * totpPeriod * time.Second), skew: 1},
+		{name: "one step late without skew", secret: rfc6238Secret, code: "050471", at: at.Add(totpPeriod * time.Second)},
+		{name: "wrong code", secret: rfc6238Secret, code: "050472", at: at, skew: 1},
+		{name: "eight digits", secret: rfc6238Secret, code: "07081804", at: at, skew: 1},
+		{name: "empty code", secret: rfc6238Secret, code: "", at: at, skew: 1},
+		{name: "invalid secret", secret: "not base32!", code: "050471", at: at, skew: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := VerifyTOTP(tc.secret, tc.code, tc.at, tc.skew)
+			if ok != tc.wantOK || got != tc.wantStep {
+				t.Errorf("VerifyTOTP = (%d, %v), want (%d, %v)", got, ok, tc.wantStep, tc.wantOK)
+			}
+		})
+	}
+}
+
+// Callers refuse a step at or below the last one used, so a code must map to
+// the same step however often and whenever within the skew window it is sent.
+func TestVerifyTOTPReplayReportsSameStep(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+
+	first, ok := VerifyTOTP(rfc6238Secret, "050471", at, 1)
+	if !ok {
+		t.Fatal("code rejected")
+	}
+
+	for _, d := range []time.Duration{0, time.Second, totpPeriod * time.Second} {
+		again, ok := VerifyTOTP(rfc6238Secret, "050471", at.Add(d), 1)
+		if !ok || again != first {
+			t.Errorf("replay after %s: (%d, %v), want step %d", d, again, ok, first)
+		}
+	}
+
+	// The next code moves to a later step, which is accepted after the first
+	next := totpCode([]byte("12345678901234567890"), uint64(first+1))
+	step, ok := VerifyTOTP(rfc6238Secret, next, at.Add(totpPeriod*time.Second), 1)
+	if !ok || step <= first {
+		t.Errorf("next code: (%d, %v), want a step after %d", step, ok, first)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	got := TOTPURI("Golang API", "john@example.com", "JBSWY3DPEHPK3PXP")
+	want := "otpauth://totp/Golang%20API:john@example.com?algorithm=SHA1&digits=6&issuer=Golang+API&period=30&secret=JBSWY3DPEHPK3PXP"
+	if got != want {
+		t.Errorf("TOTPURI = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/service"
+)
+
+// AdminHandler groups admin-only routes. Role enforcement is applied where it is mounted.
+func AdminHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	userRepo := repository.NewUserRepository(db.DB)
+	apiKeyRepo := repository.NewAPIKeyRepository(db.DB)
+	oauthRepo := repository.NewOAuthRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+	tokenRepo := repository.NewTokenRepository(db.DB)
+	mux.HandleFunc("POST /users/{uuid}/unlock", service.AdminUnlockUserHandler(userRepo))
+	mux.HandleFunc("POST /users/{uuid}/impersonate", service.AdminImpersonateUserHandler(userRepo, auditRepo))
+	mux.HandleFunc("POST /users/{uuid}/restore", service.AdminRestoreUserHandler(userRepo, auditRepo))
+	mux.HandleFunc("POST /users/{uuid}/status", service.AdminChangeUserStatusHandler(userRepo, tokenRepo))
+	mux.HandleFunc("GET /users/{uuid}/status-history", service.AdminUserStatusHistoryHandler(userRepo))
+	mux.HandleFunc("POST /api-keys", service.AdminCreateAPIKeyHandler(apiKeyRepo, userRepo))
+	mux.HandleFunc("GET /api-keys", service.AdminListAPIKeysHandler(apiKeyRepo))
+	mux.HandleFunc("DELETE /api-keys/{id}", service.AdminRevokeAPIKeyHandler(apiKeyRepo))
+	mux.HandleFunc("POST /oauth/clients", service.AdminCreateOAuthClientHandler(oauthRepo))
+	mux.HandleFunc("GET /oauth/clients", service.AdminListOAuthClientsHandler(oauthRepo))
+	mux.HandleFunc("DELETE /oauth/clients/{client_id}", service.AdminRevokeOAuthClientHandler(oauthRepo))
+
+	// Catch-all for professional 404/405
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		response.NotFound(response.SendParams{
+			W:       w,
+			Message: "Admin endpoint not found or invalid method",
+		})
+	})
+
+	return mux
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/service"
@@ -13,8 +14,24 @@ func AuthHandler() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	authRepo := repository.NewAuthRepository(db.DB)
+	tokenRepo := repository.NewTokenRepository(db.DB)
+	userTokenRepo := repository.NewUserTokenRepository(db.DB)
+	mfaRepo := repository.NewMFARepository(db.DB)
+	identityRepo := repository.NewIdentityRepository(db.DB)
 	mux.HandleFunc("POST /login", service.LoginHandler(authRepo))
-	mux.HandleFunc("POST /sign-up", service.SignUpHandler(authRepo))
+	mux.HandleFunc("POST /2fa/verify", service.MFAVerifyHandler(authRepo, mfaRepo))
+	mux.HandleFunc("POST /sign-up", service.SignUpHandler(authRepo, userTokenRepo))
+	mux.HandleFunc("POST /refresh", service.RefreshTokenHandler(authRepo, tokenRepo))
+	mux.Handle("POST /logout", middleware.JWT(service.LogoutHandler(tokenRepo)))
+	mux.Handle("POST /logout-all", middleware.JWT(service.LogoutAllHandler(tokenRepo)))
+	mux.HandleFunc("POST /forgot-password", service.ForgotPasswordHandler(authRepo, userTokenRepo))
+	mux.HandleFunc("POST /reset-password", service.ResetPasswordHandler(authRepo, userTokenRepo, tokenRepo))
+	mux.HandleFunc("POST /verify-email", service.VerifyEmailHandler(authRepo, userTokenRepo))
+	mux.HandleFunc("POST /resend-verification", service.ResendVerificationHandler(authRepo, userTokenRepo))
+	mux.HandleFunc("POST /magic-link", service.MagicLinkHandler(authRepo, userTokenRepo))
+	mux.HandleFunc("POST /magic-link/consume", service.MagicLinkConsumeHandler(authRepo, userTokenRepo))
+	mux.HandleFunc("POST /oidc/{provider}/start", service.OIDCStartHandler(identityRepo))
+	mux.HandleFunc("POST /oidc/{provider}/callback", service.OIDCCallbackHandler(authRepo, identityRepo))
 
 	// Catch-all for professional 404/405
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
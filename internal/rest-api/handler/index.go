@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apikey"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
 	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
-	_ "github.com/lakhan-purohit/net-http/internal/rest-api/service"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/service"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -64,6 +67,12 @@ func GetHandler() *http.ServeMux {
 		`, string(spec))
 	})
 
+	// Public keys for verifying our tokens
+	root.Handle("GET /.well-known/jwks.json", middleware.Public(service.JWKSHandler()))
+
+	// OAuth2 provider
+	root.Handle("/oauth/", http.StripPrefix("/oauth", middleware.Public(OAuthHandler())))
+
 	root.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		// Only match "/" exactly for the Home Page
 		if r.URL.Path != "/" {
@@ -81,18 +90,21 @@ func GetHandler() *http.ServeMux {
 	})
 
 	apiV1 := http.NewServeMux()
+	clientAuth := config.Get().ClientAuth
 
 	// Public routes
 	apiV1.Handle("/public/",
-		http.StripPrefix("/public",
-			middleware.Public(unAuthenticated()),
-		))
+		http.StripPrefix("/public", middleware.Protected(
+			middleware.Public(middleware.RequireAPIKeyScope(apikey.ScopeAuth)(unAuthenticated())),
+			clientAuth.Public,
+		)))
 
 	// Private routes
 	apiV1.Handle("/private/",
-		http.StripPrefix("/private",
+		http.StripPrefix("/private", middleware.Protected(
 			middleware.Private(authenticated()),
-		))
+			clientAuth.Private,
+		)))
 
 	// API v1 Catch-all 404
 	apiV1.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -103,9 +115,7 @@ func GetHandler() *http.ServeMux {
 	})
 
 	// Mount apiV1 routes
-	root.Handle("/api/v1/", http.StripPrefix("/api/v1", middleware.Protected(
-		apiV1,
-	)))
+	root.Handle("/api/v1/", http.StripPrefix("/api/v1", apiV1))
 
 	return root
 }
@@ -114,7 +124,14 @@ func GetHandler() *http.ServeMux {
 func authenticated() *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.Handle("/user/", http.StripPrefix("/user", UserHandler()))
+	mux.Handle("/user/", http.StripPrefix("/user",
+		middleware.RequireAPIKeyScope(apikey.ScopeUser)(UserHandler()),
+	))
+	mux.Handle("/admin/", http.StripPrefix("/admin",
+		middleware.RequireAPIKeyScope(apikey.ScopeAdmin)(
+			middleware.RequireRole(constants.RoleAdmin)(AdminHandler()),
+		),
+	))
 
 	// Catch-all 404 for Private
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
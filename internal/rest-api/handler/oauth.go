@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/service"
+)
+
+// OAuthHandler serves the OAuth2 provider endpoints. Third-party clients
+// authenticate with their own credentials, so it is mounted outside the
+// x-api-key protected /api/v1 tree.
+func OAuthHandler() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	oauthRepo := repository.NewOAuthRepository(db.DB)
+	authRepo := repository.NewAuthRepository(db.DB)
+	tokenRepo := repository.NewTokenRepository(db.DB)
+	mux.Handle("GET /authorize", middleware.JWT(service.OAuthConsentHandler(oauthRepo)))
+	mux.Handle("POST /authorize", middleware.JWT(service.OAuthAuthorizeHandler(oauthRepo)))
+	mux.HandleFunc("POST /token", service.OAuthTokenHandler(oauthRepo, authRepo, tokenRepo))
+	mux.HandleFunc("POST /introspect", service.OAuthIntrospectHandler(oauthRepo, tokenRepo))
+	mux.HandleFunc("POST /revoke", service.OAuthRevokeHandler(oauthRepo, tokenRepo))
+	mux.Handle("GET /userinfo", middleware.OAuthBearer(constants.ScopeProfile)(service.OAuthUserInfoHandler(authRepo)))
+
+	// Catch-all for professional 404/405
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		response.NotFound(response.SendParams{
+			W:       w,
+			Message: "OAuth endpoint not found or invalid method",
+		})
+	})
+
+	return mux
+}
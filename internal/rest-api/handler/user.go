@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/rbac"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/service"
@@ -14,8 +16,22 @@ func UserHandler() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	r := repository.NewUserRepository(db.DB)
-	mux.HandleFunc("GET /get-list", service.UserGetListHandler(r))
-	mux.HandleFunc("GET /get-full-list", service.UserGetFullListHandler(r))
+	tokenRepo := repository.NewTokenRepository(db.DB)
+	mfaRepo := repository.NewMFARepository(db.DB)
+	userTokenRepo := repository.NewUserTokenRepository(db.DB)
+	mux.Handle("GET /get-list", middleware.RequirePermission(rbac.PermUserList)(service.UserGetListHandler(r)))
+	mux.Handle("GET /get-full-list", middleware.RequirePermission(rbac.PermUserStats)(service.UserGetFullListHandler(r)))
+	mux.HandleFunc("GET /sessions", service.SessionListHandler(tokenRepo))
+	mux.HandleFunc("DELETE /sessions/{id}", service.SessionRevokeHandler(tokenRepo))
+	mux.HandleFunc("POST /2fa/setup", service.MFASetupHandler(mfaRepo))
+	mux.HandleFunc("POST /2fa/confirm", service.MFAConfirmHandler(mfaRepo))
+	mux.HandleFunc("GET /users/me", service.UserMeHandler(r))
+	mux.HandleFunc("GET /users/{uuid}", service.UserGetHandler(r))
+	mux.HandleFunc("PATCH /users/{uuid}", service.UserUpdateHandler(r))
+	mux.HandleFunc("DELETE /users/{uuid}", service.UserDeleteHandler(r, tokenRepo))
+	mux.HandleFunc("POST /change-password", service.ChangePasswordHandler(r, tokenRepo))
+	mux.HandleFunc("POST /change-email", service.ChangeEmailHandler(r, userTokenRepo))
+	mux.HandleFunc("POST /change-email/confirm", service.ChangeEmailConfirmHandler(r, userTokenRepo, tokenRepo))
 
 	// Catch-all for professional 404/405
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
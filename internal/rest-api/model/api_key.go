@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// APIKey is a managed x-api-key. Key holds the plaintext and is only set in
+// the response to its creation.
+// @Description API key
+type APIKey struct {
+	ID         int64      `json:"id" db:"id" example:"1"`
+	Name       string     `json:"name" db:"name" example:"billing-service"`
+	Prefix     string     `json:"prefix" db:"prefix" example:"3f9c2a71be04"`
+	OwnerUUID  string     `json:"owner_uuid" db:"owner_uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Scopes     []string   `json:"scopes" example:"user"`
+	ScopeList  string     `json:"-" db:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Key        string     `json:"key,omitempty" example:"ak_3f9c2a71be04_9d1e..."`
+}
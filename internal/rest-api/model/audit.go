@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// AuditLog records a privileged action taken by an admin
+type AuditLog struct {
+	ID           int64     `json:"id" db:"id"`
+	ActorID      int64     `json:"actor_id" db:"actor_id"`
+	Action       string    `json:"action" db:"action"`
+	TargetUserID int64     `json:"target_user_id" db:"target_user_id"`
+	Reason       string    `json:"reason" db:"reason"`
+	TokenID      string    `json:"token_id,omitempty" db:"token_id"`
+	IPAddress    string    `json:"ip_address" db:"ip_address"`
+	UserAgent    string    `json:"user_agent" db:"user_agent"`
+	RequestID    string    `json:"request_id" db:"request_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// UserIdentity links a user to an account at an external OIDC provider
+type UserIdentity struct {
+	ID       int64  `db:"id"`
+	UserID   int64  `db:"user_id"`
+	Provider string `db:"provider"`
+	Subject  string `db:"subject"`
+	Email    string `db:"email"`
+}
+
+// OIDCLoginState is a started external login waiting for the provider callback
+type OIDCLoginState struct {
+	ID           int64     `db:"id"`
+	Provider     string    `db:"provider"`
+	Nonce        string    `db:"nonce"`
+	CodeVerifier string    `db:"code_verifier"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// OIDCStart tells the client where to send the user to sign in
+// @Description External sign-in started
+type OIDCStart struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://login.example.com/authorize?client_id=...&state=..."`
+	State            string `json:"state" example:"3f1c..."` // compare with the state on the callback before posting it
+}
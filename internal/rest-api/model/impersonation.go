@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Impersonation is an access token an admin can use as another user. It
+// cannot be refreshed.
+// @Description Impersonation token
+type Impersonation struct {
+	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
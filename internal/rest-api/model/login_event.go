@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// LoginEvent is one login attempt, successful or not
+type LoginEvent struct {
+	ID            int64     `json:"id" db:"id"`
+	UserID        *int64    `json:"user_id" db:"user_id"`
+	Email         string    `json:"email" db:"email"`
+	Success       bool      `json:"success" db:"success"`
+	FailureReason string    `json:"failure_reason,omitempty" db:"failure_reason"`
+	IPAddress     string    `json:"ip_address" db:"ip_address"`
+	UserAgent     string    `json:"user_agent" db:"user_agent"`
+	RequestID     string    `json:"request_id" db:"request_id"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
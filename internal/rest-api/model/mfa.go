@@ -0,0 +1,34 @@
+package model
+
+// TOTPState is the 2FA enrolment of a user
+type TOTPState struct {
+	Secret   string `db:"totp_secret"`
+	Enabled  bool   `db:"totp_enabled"`
+	LastStep *int64 `db:"totp_last_step"`
+}
+
+// RecoveryCode is a hashed single-use 2FA backup code
+type RecoveryCode struct {
+	ID       int64  `db:"id"`
+	CodeHash string `db:"code_hash"`
+}
+
+// MFAChallenge is returned by login instead of tokens when a second factor is required
+// @Description Second factor required
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// MFASetup is the secret to load into an authenticator app
+// @Description TOTP enrolment details
+type MFASetup struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Golang%20API:john@example.com?secret=JBSWY3DPEHPK3PXP"`
+}
+
+// MFARecoveryCodes are shown once, when 2FA is enabled
+// @Description One-time recovery codes
+type MFARecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7f2m-9xq4p"`
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// OAuthClient is a registered partner application. ClientSecret holds the
+// plaintext secret and is only set in the response to its creation.
+// @Description OAuth2 client
+type OAuthClient struct {
+	ID            int64      `json:"-" db:"id"`
+	ClientID      string     `json:"client_id" db:"client_id" example:"c_5f2b9e0d7a61"`
+	SecretHash    *string    `json:"-" db:"secret_hash"`
+	Name          string     `json:"name" db:"name" example:"Partner App"`
+	RedirectURIs  []string   `json:"redirect_uris" example:"https://partner.example.com/callback"`
+	RedirectList  string     `json:"-" db:"redirect_uris"`
+	Scopes        []string   `json:"scopes" example:"profile"`
+	ScopeList     string     `json:"-" db:"scopes"`
+	GrantTypes    []string   `json:"grant_types" example:"authorization_code"`
+	GrantTypeList string     `json:"-" db:"grant_types"`
+	Confidential  bool       `json:"confidential" example:"true"`
+	RevokedAt     *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	ClientSecret  string     `json:"client_secret,omitempty" example:"9d1e..."`
+}
+
+func (c *OAuthClient) AllowsGrant(grant string) bool {
+	return slices.Contains(c.GrantTypes, grant)
+}
+
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// AuthorizationCode is a pending authorization_code grant
+type AuthorizationCode struct {
+	ID            int64     `db:"id"`
+	ClientID      string    `db:"client_id"`
+	UserID        int64     `db:"user_id"`
+	RedirectURI   string    `db:"redirect_uri"`
+	Scope         string    `db:"scope"`
+	CodeChallenge string    `db:"code_challenge"`
+	ExpiresAt     time.Time `db:"expires_at"`
+}
+
+// OAuthConsent describes an authorization request so the user can approve it
+// @Description Pending OAuth2 authorization
+type OAuthConsent struct {
+	ClientID   string   `json:"client_id" example:"c_5f2b9e0d7a61"`
+	ClientName string   `json:"client_name" example:"Partner App"`
+	Scopes     []string `json:"scopes" example:"profile"`
+}
+
+// OAuthRedirect is where the user agent should be sent after an authorization decision
+// @Description OAuth2 authorization result
+type OAuthRedirect struct {
+	RedirectTo string `json:"redirect_to" example:"https://partner.example.com/callback?code=...&state=xyz"`
+}
+
+// OAuthToken is the RFC 6749 token response
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type" example:"Bearer"`
+	ExpiresIn    int64  `json:"expires_in" example:"3600"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty" example:"profile offline_access"`
+}
+
+// OAuthError is the RFC 6749 error response
+type OAuthError struct {
+	Error       string `json:"error" example:"invalid_grant"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// Introspection is the RFC 7662 introspection response
+type Introspection struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Iat       int64    `json:"iat,omitempty"`
+	Nbf       int64    `json:"nbf,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+}
+
+// UserInfo is what a delegated token may read about its user
+// @Description OAuth2 user info
+type UserInfo struct {
+	Sub      string `json:"sub" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Username string `json:"username,omitempty" example:"johndoe"`
+	Avatar   string `json:"avatar,omitempty" example:"avatar.jpg"`
+	Email    string `json:"email,omitempty" example:"john@example.com"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// RefreshToken is the persisted state of an issued refresh token.
+// Tokens rotated from the same login share a FamilyID.
+type RefreshToken struct {
+	ID         int64      `json:"-" db:"id"`
+	TokenID    string     `json:"-" db:"token_id"`
+	FamilyID   string     `json:"-" db:"family_id"`
+	UserID     int64      `json:"-" db:"user_id"`
+	ReplacedBy *string    `json:"-" db:"replaced_by"`
+	UserAgent  string     `json:"-" db:"user_agent"`
+	IPAddress  string     `json:"-" db:"ip_address"`
+	ClientID   string     `json:"-" db:"client_id"`
+	Scope      string     `json:"-" db:"scope"`
+	ExpiresAt  time.Time  `json:"-" db:"expires_at"`
+	UsedAt     *time.Time `json:"-" db:"used_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"-" db:"created_at"`
+}
+
+// Session is an active login on one device, backed by a refresh token family
+// @Description Active login session
+type Session struct {
+	ID         string    `json:"id" db:"id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+	UserAgent  string    `json:"user_agent" db:"user_agent" example:"Mozilla/5.0"`
+	IPAddress  string    `json:"ip_address" db:"ip_address" example:"203.0.113.7"`
+	ClientID   string    `json:"client_id,omitempty" db:"client_id" example:"c_5f2b9e0d7a61"` // OAuth app the session was granted to
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+	Current    bool      `json:"current" example:"true"`
+}
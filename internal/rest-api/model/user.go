@@ -1,14 +1,43 @@
 package model
 
+import (
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/pagination"
+)
+
 // User represents a user in the system
 // @Description User account information
 type User struct {
-	UUID         string `json:"uuid" db:"uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
-	ID           int64  `json:"id" db:"id" example:"1"`
-	Username     string `json:"username" db:"username" example:"johndoe"`
-	Email        string `json:"email" db:"email" example:"john@example.com"`
-	Status       int    `json:"status" db:"status" example:"1"`
-	Avatar       string `json:"avatar" db:"avatar" example:"avatar.jpg"`
-	Token        string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	RefreshToken string `json:"refresh_token" example:"def456..."`
+	UUID         string     `json:"uuid" db:"uuid" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ID           int64      `json:"id" db:"id" example:"1"`
+	Username     string     `json:"username" db:"username" example:"johndoe"`
+	Email        string     `json:"email" db:"email" example:"john@example.com"`
+	Status       int        `json:"status" db:"status" example:"1"`
+	StatusUntil  *time.Time `json:"status_until,omitempty" db:"status_until"` // end of a temporary ban or suspension
+	Role         string     `json:"role" db:"role" example:"user"`
+	Avatar       string     `json:"avatar" db:"avatar" example:"avatar.jpg"`
+	Token        string     `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string     `json:"refresh_token" example:"def456..."`
+	MFAEnabled   bool       `json:"-" db:"totp_enabled"`
+	CreatedAt    *time.Time `json:"created_at,omitempty" db:"created_at"` // set by the list queries
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // only set for deleted users
+}
+
+// UserFilter narrows down and orders a user list. It is sent back with the
+// results so clients see exactly what was applied.
+// @Description Filters applied to a user list
+type UserFilter struct {
+	Status      *int       `json:"status,omitempty" example:"1"`
+	Email       string     `json:"email,omitempty" example:"john"` // address prefix
+	CreatedFrom *time.Time `json:"created_from,omitempty"`         // inclusive
+	CreatedTo   *time.Time `json:"created_to,omitempty"`           // exclusive
+	Query       string     `json:"q,omitempty" example:"john"`     // full-text search on username and email
+	Sort        []string   `json:"sort" example:"-created_at,username"`
+
+	// Cursor continues the list from a position; only valid when sorting by
+	// created_at alone
+	Cursor *pagination.Cursor `json:"-"`
+	Limit  int                `json:"-"`
+	Offset int                `json:"-"`
 }
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// StatusChange is an entry in a user's status history
+// @Description A status change and who made it
+type StatusChange struct {
+	ID          int64      `json:"id" db:"id" example:"1"`
+	UserID      int64      `json:"-" db:"user_id"`
+	FromStatus  int        `json:"from_status" db:"from_status" example:"1"`
+	ToStatus    int        `json:"to_status" db:"to_status" example:"4"`
+	Reason      string     `json:"reason" db:"reason" example:"Spam reports, see ticket #4711"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	ChangedByID *int64     `json:"-" db:"changed_by"`
+	ChangedBy   string     `json:"changed_by,omitempty" db:"changed_by_uuid" example:"550e8400-e29b-41d4-a716-446655440000"` // admin UUID; empty when a temporary status expired
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
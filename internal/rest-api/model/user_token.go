@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// UserToken is a hashed, single-use code sent to a user (e.g. password reset).
+// Target optionally binds the token to a value such as an email address.
+type UserToken struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	Target    string     `db:"target"`
+	Attempts  int        `db:"attempts"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
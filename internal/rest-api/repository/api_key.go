@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apikey"
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type IAPIKeyRepository interface {
+	Create(ctx context.Context, k *model.APIKey, ownerID int64, hash string) error
+	List(ctx context.Context) ([]*model.APIKey, error)
+	FindByID(ctx context.Context, id int64) (*model.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+}
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const selectAPIKeyQuery = `
+	SELECT k.id, k.name, k.prefix, u.uuid AS owner_uuid, k.scopes,
+		k.expires_at, k.last_used_at, k.revoked_at, k.created_at
+	FROM api_keys k
+	JOIN users u ON u.id = k.owner_id AND u.deleted_at IS NULL
+`
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *model.APIKey, ownerID int64, hash string) error {
+	id, err := db.Insert(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, owner_id, scopes, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, k.Name, k.Prefix, hash, ownerID, apikey.JoinScopes(k.Scopes), k.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	k.ID = id
+	return nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context) ([]*model.APIKey, error) {
+	keys := []*model.APIKey{}
+	if err := db.FindAll(ctx, selectAPIKeyQuery+" ORDER BY k.id DESC", &keys); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		k.Scopes = apikey.SplitScopes(k.ScopeList)
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id int64) (*model.APIKey, error) {
+	var k model.APIKey
+	if err := db.FindOne(ctx, selectAPIKeyQuery+" WHERE k.id = ?", &k, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	k.Scopes = apikey.SplitScopes(k.ScopeList)
+	return &k, nil
+}
+
+// Revoke marks the key revoked, returning ErrNotFound if it does not exist or is already revoked
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	affected, err := db.Update(ctx, "UPDATE api_keys SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type IAuditRepository interface {
+	Record(ctx context.Context, e *model.AuditLog) error
+}
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Record(ctx context.Context, e *model.AuditLog) error {
+	id, err := db.Insert(ctx, `
+		INSERT INTO audit_logs (actor_id, action, target_user_id, reason, token_id, ip_address, user_agent, request_id)
+		VALUES (?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)
+	`, e.ActorID, e.Action, e.TargetUserID, e.Reason, e.TokenID, e.IPAddress, e.UserAgent, e.RequestID)
+	if err != nil {
+		return err
+	}
+	e.ID = id
+	return nil
+}
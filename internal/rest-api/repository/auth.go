@@ -3,16 +3,34 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
 	"github.com/lakhan-purohit/net-http/internal/pkg/db"
 	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
 )
 
+// IPasswordHistory is implemented by the repositories that change passwords
+type IPasswordHistory interface {
+	PasswordHistory(ctx context.Context, userID int64, limit int) ([]string, error)
+}
+
 type IAuthRepository interface {
+	IPasswordHistory
 	Login(ctx context.Context, email, password string) (*model.User, error)
 	SignUp(username, email, password, avatar string) (*model.User, error)
+	FindByID(ctx context.Context, id int64) (*model.User, error)
+	CompleteLogin(ctx context.Context, userID int64, session *model.RefreshToken) error
+	RecordLoginEvent(ctx context.Context, e *model.LoginEvent) error
+	LockedUntil(ctx context.Context, email string) (*time.Time, error)
+	LockAccount(ctx context.Context, email string, until time.Time) error
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	ResetPassword(ctx context.Context, userID, tokenID int64, password string) error
+	VerifyEmail(ctx context.Context, userID, tokenID int64) error
 }
 
 type AuthRepository struct {
@@ -29,9 +47,9 @@ func (r *AuthRepository) Login(
 ) (*model.User, error) {
 
 	query := `
-		SELECT uuid, id, username, email, status, password
+		SELECT uuid, id, username, email, status, status_until, role, COALESCE(avatar, '') AS avatar, totp_enabled, password
 		FROM users
-		WHERE email = ?
+		WHERE email = ? AND deleted_at IS NULL
 		LIMIT 1
 	`
 
@@ -43,18 +61,38 @@ func (r *AuthRepository) Login(
 
 	if err := db.FindOne(ctx, query, &result, email); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("invalid credentials")
+			return nil, apperr.ErrInvalidCredentials
 		}
 		return nil, err
 	}
 
 	if !utils.ComparePassword(result.Password, password) {
-		return nil, errors.New("invalid credentials")
+		return nil, apperr.ErrInvalidCredentials
+	}
+
+	if utils.PasswordNeedsRehash(result.Password) {
+		r.rehashPassword(ctx, result.ID, result.Password, password)
 	}
 
 	return &result.User, nil
 }
 
+// rehashPassword upgrades a stored hash to the configured hasher while the
+// plain password is at hand. A failure only delays the upgrade to the next
+// login, so it does not fail this one.
+func (r *AuthRepository) rehashPassword(ctx context.Context, userID int64, oldHash, password string) {
+	newHash, err := utils.HashPassword(password)
+	if err != nil {
+		slog.Warn("password_rehash_failed", "user_id", userID, "error", err)
+		return
+	}
+
+	// Matching the old hash keeps a concurrent password change from being overwritten
+	if _, err := db.Update(ctx, "UPDATE users SET password = ? WHERE id = ? AND password = ? AND deleted_at IS NULL", newHash, userID, oldHash); err != nil {
+		slog.Warn("password_rehash_failed", "user_id", userID, "error", err)
+	}
+}
+
 func (r *AuthRepository) SignUp(userName string, email string, password string, avatar string) (*model.User, error) {
 	passwordHash, err := utils.HashPassword(password)
 	if err != nil {
@@ -63,10 +101,12 @@ func (r *AuthRepository) SignUp(userName string, email string, password string,
 
 	uuid := utils.UUID()
 	query := `
-		INSERT INTO users (uuid, username, email, password, avatar)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (uuid, username, email, password, avatar, role, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
-	userID, err := db.Insert(context.Background(), query, uuid, userName, email, passwordHash, avatar)
+	userID, err := db.Insert(context.Background(), query,
+		uuid, userName, email, passwordHash, avatar, constants.RoleUser, constants.UserStatusPending,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +115,221 @@ func (r *AuthRepository) SignUp(userName string, email string, password string,
 		Username: userName,
 		Email:    email,
 		UUID:     uuid,
-		Status:   1,
+		Status:   constants.UserStatusPending,
+		Role:     constants.RoleUser,
 		Avatar:   avatar,
 	}, nil
 }
+
+func (r *AuthRepository) FindByID(ctx context.Context, id int64) (*model.User, error) {
+	query := `
+		SELECT uuid, id, username, email, status, status_until, role, COALESCE(avatar, '') AS avatar, totp_enabled
+		FROM users
+		WHERE id = ? AND deleted_at IS NULL
+		LIMIT 1
+	`
+
+	var user model.User
+	if err := db.FindOne(ctx, query, &user, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CompleteLogin records a successful login: it bumps user_stats and stores the
+// refresh token of the new session in a single transaction.
+func (r *AuthRepository) CompleteLogin(ctx context.Context, userID int64, session *model.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = db.ExecTx(ctx, tx, `
+		INSERT INTO user_stats (user_id, last_login, login_count)
+		VALUES (?, NOW(), 1)
+		ON DUPLICATE KEY UPDATE last_login = NOW(), login_count = login_count + 1
+	`, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := createRefreshTokenTx(ctx, tx, session); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RecordLoginEvent appends to the login history. The user is resolved from the
+// email so failed attempts against an existing account are attributed too.
+func (r *AuthRepository) RecordLoginEvent(ctx context.Context, e *model.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (user_id, email, success, failure_reason, ip_address, user_agent, request_id)
+		VALUES ((SELECT id FROM users WHERE email = ? AND deleted_at IS NULL LIMIT 1), ?, ?, NULLIF(?, ''), ?, ?, ?)
+	`
+	id, err := db.Insert(ctx, query,
+		e.Email, e.Email, e.Success, e.FailureReason, e.IPAddress, e.UserAgent, e.RequestID,
+	)
+	if err != nil {
+		return err
+	}
+	e.ID = id
+	return nil
+}
+
+// LockedUntil returns when the account's lock ends, or nil if it is not locked
+func (r *AuthRepository) LockedUntil(ctx context.Context, email string) (*time.Time, error) {
+	var result struct {
+		LockedUntil *time.Time `db:"locked_until"`
+	}
+
+	query := `
+		SELECT locked_until
+		FROM users
+		WHERE email = ? AND locked_until > NOW() AND deleted_at IS NULL
+		LIMIT 1
+	`
+	if err := db.FindOne(ctx, query, &result, email); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return result.LockedUntil, nil
+}
+
+func (r *AuthRepository) LockAccount(ctx context.Context, email string, until time.Time) error {
+	_, err := db.Update(ctx, "UPDATE users SET locked_until = ? WHERE email = ? AND deleted_at IS NULL", until, email)
+	return err
+}
+
+func (r *AuthRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `
+		SELECT uuid, id, username, email, status, status_until, role, COALESCE(avatar, '') AS avatar, totp_enabled
+		FROM users
+		WHERE email = ? AND deleted_at IS NULL
+		LIMIT 1
+	`
+
+	var user model.User
+	if err := db.FindOne(ctx, query, &user, email); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResetPassword redeems the reset token and sets the new password atomically
+func (r *AuthRepository) ResetPassword(ctx context.Context, userID, tokenID int64, password string) error {
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := consumeUserTokenTx(ctx, tx, tokenID); err != nil {
+		return err
+	}
+
+	if err := setPasswordTx(ctx, tx, userID, passwordHash); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *AuthRepository) PasswordHistory(ctx context.Context, userID int64, limit int) ([]string, error) {
+	return findPasswordHistory(ctx, userID, limit)
+}
+
+// findPasswordHistory returns the current password hash followed by previous
+// ones, newest first, at most limit in total
+func findPasswordHistory(ctx context.Context, userID int64, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	query := `
+		(SELECT password AS password_hash, 0 AS history_id FROM users WHERE id = ? AND deleted_at IS NULL)
+		UNION ALL
+		(SELECT password_hash, id AS history_id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?)
+		ORDER BY history_id = 0 DESC, history_id DESC
+	`
+
+	var rows []struct {
+		PasswordHash string `db:"password_hash"`
+	}
+	if err := db.FindAll(ctx, query, &rows, userID, userID, limit-1); err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(rows))
+	for i, row := range rows {
+		hashes[i] = row.PasswordHash
+	}
+	return hashes, nil
+}
+
+// setPasswordTx replaces a user's password, moving the old hash into
+// password_history and pruning entries the configured history no longer needs
+func setPasswordTx(ctx context.Context, tx *sql.Tx, userID int64, passwordHash string) error {
+	keep := config.Get().Password.History - 1 // the current password is the newest entry
+
+	if keep > 0 {
+		if _, err := db.ExecTx(ctx, tx,
+			"INSERT INTO password_history (user_id, password_hash) SELECT id, password FROM users WHERE id = ? AND deleted_at IS NULL",
+			userID,
+		); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.ExecTx(ctx, tx, "UPDATE users SET password = ? WHERE id = ? AND deleted_at IS NULL", passwordHash, userID); err != nil {
+		return err
+	}
+
+	// MySQL cannot LIMIT inside IN (...), hence the derived table
+	_, err := db.ExecTx(ctx, tx, `
+		DELETE FROM password_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM (
+				SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+			) AS kept
+		)
+	`, userID, userID, max(keep, 0))
+	return err
+}
+
+// VerifyEmail redeems the verification token and activates the pending user atomically
+func (r *AuthRepository) VerifyEmail(ctx context.Context, userID, tokenID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := consumeUserTokenTx(ctx, tx, tokenID); err != nil {
+		return err
+	}
+
+	_, err = db.ExecTx(ctx, tx,
+		"UPDATE users SET status = ? WHERE id = ? AND status = ? AND deleted_at IS NULL",
+		constants.UserStatusActive, userID, constants.UserStatusPending,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type IIdentityRepository interface {
+	CreateLoginState(ctx context.Context, stateHash string, s *model.OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, stateHash string) (*model.OIDCLoginState, error)
+	FindUser(ctx context.Context, provider, subject string) (*model.User, error)
+	Link(ctx context.Context, userID int64, provider, subject, email string) error
+	CreateUser(ctx context.Context, user *model.User, passwordHash string, provider, subject string) error
+}
+
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) *IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+func (r *IdentityRepository) CreateLoginState(ctx context.Context, stateHash string, s *model.OIDCLoginState) error {
+	id, err := db.Insert(ctx, `
+		INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, stateHash, s.Provider, s.Nonce, s.CodeVerifier, s.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	s.ID = id
+	return nil
+}
+
+// ConsumeLoginState burns an unexpired state and returns it, so a provider
+// callback can only be completed once.
+func (r *IdentityRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*model.OIDCLoginState, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE oidc_login_states
+		SET used_at = NOW()
+		WHERE state_hash = ? AND used_at IS NULL AND expires_at > NOW()
+	`, stateHash)
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, apperr.ErrInvalidLoginState
+	}
+
+	var s model.OIDCLoginState
+	if err := db.FindOneTx(ctx, tx, `
+		SELECT id, provider, nonce, code_verifier, expires_at
+		FROM oidc_login_states
+		WHERE state_hash = ?
+	`, &s, stateHash); err != nil {
+		return nil, err
+	}
+
+	return &s, tx.Commit()
+}
+
+// FindUser returns the user linked to an external identity and records the login
+func (r *IdentityRepository) FindUser(ctx context.Context, provider, subject string) (*model.User, error) {
+	query := `
+		SELECT u.uuid, u.id, u.username, u.email, u.status, u.status_until, u.role, COALESCE(u.avatar, '') AS avatar, u.totp_enabled
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id AND u.deleted_at IS NULL
+		WHERE i.provider = ? AND i.subject = ?
+		LIMIT 1
+	`
+
+	var user model.User
+	if err := db.FindOne(ctx, query, &user, provider, subject); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+
+	_, err := db.Update(ctx,
+		"UPDATE user_identities SET last_login_at = NOW() WHERE provider = ? AND subject = ?",
+		provider, subject,
+	)
+	return &user, err
+}
+
+func (r *IdentityRepository) Link(ctx context.Context, userID int64, provider, subject, email string) error {
+	_, err := db.Insert(ctx, `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), NOW())
+	`, userID, provider, subject, email)
+	return err
+}
+
+// CreateUser creates an active account for a first-time external login and
+// links the identity to it in one transaction. The password hash should be of
+// a random value nobody knows, so the account can only sign in externally
+// until a password is set through the reset flow.
+func (r *IdentityRepository) CreateUser(ctx context.Context, user *model.User, passwordHash string, provider, subject string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	user.UUID = utils.UUID()
+	user.Role = constants.RoleUser
+	user.Status = constants.UserStatusActive
+
+	user.ID, err = db.InsertTx(ctx, tx, `
+		INSERT INTO users (uuid, username, email, password, avatar, role, status)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?)
+	`, user.UUID, user.Username, user.Email, passwordHash, user.Avatar, user.Role, user.Status)
+	if db.IsDuplicateKey(err) {
+		// Live accounts are linked before we get here, so the email belongs to a
+		// deleted account that has not been purged yet (or one created meanwhile)
+		return apperr.ErrAccountUnavailable
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.InsertTx(ctx, tx, `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES (?, ?, ?, ?, NOW())
+	`, user.ID, provider, subject, user.Email); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
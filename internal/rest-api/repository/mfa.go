@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type IMFARepository interface {
+	GetTOTP(ctx context.Context, userID int64) (*model.TOTPState, error)
+	SetPendingSecret(ctx context.Context, userID int64, secret string) error
+	Enable(ctx context.Context, userID, step int64, recoveryHashes []string) error
+	MarkStepUsed(ctx context.Context, userID, step int64) (bool, error)
+	ListRecoveryCodes(ctx context.Context, userID int64) ([]*model.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, id int64) (bool, error)
+}
+
+type MFARepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+func (r *MFARepository) GetTOTP(ctx context.Context, userID int64) (*model.TOTPState, error) {
+	query := `
+		SELECT COALESCE(totp_secret, '') AS totp_secret, totp_enabled, totp_last_step
+		FROM users
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	var state model.TOTPState
+	if err := db.FindOne(ctx, query, &state, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SetPendingSecret stores a secret that only becomes active once confirmed
+func (r *MFARepository) SetPendingSecret(ctx context.Context, userID int64, secret string) error {
+	_, err := db.Update(ctx, `
+		UPDATE users
+		SET totp_secret = ?, totp_enabled = 0, totp_last_step = NULL
+		WHERE id = ? AND totp_enabled = 0 AND deleted_at IS NULL
+	`, secret, userID)
+	return err
+}
+
+// Enable turns 2FA on and replaces the recovery codes in one Tx
+func (r *MFARepository) Enable(ctx context.Context, userID, step int64, recoveryHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE users
+		SET totp_enabled = 1, totp_last_step = ?
+		WHERE id = ? AND totp_enabled = 0 AND totp_secret IS NOT NULL AND deleted_at IS NULL
+	`, step, userID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrMFAAlreadyEnabled
+	}
+
+	if _, err := db.ExecTx(ctx, tx, "DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+
+	for _, hash := range recoveryHashes {
+		if _, err := db.InsertTx(ctx, tx, `
+			INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)
+		`, userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MarkStepUsed atomically records the TOTP time step as consumed. It returns
+// false if that step (or a later one) was already used, i.e. a replayed code.
+func (r *MFARepository) MarkStepUsed(ctx context.Context, userID, step int64) (bool, error) {
+	affected, err := db.Update(ctx, `
+		UPDATE users
+		SET totp_last_step = ?
+		WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?) AND deleted_at IS NULL
+	`, step, userID, step)
+	return affected == 1, err
+}
+
+func (r *MFARepository) ListRecoveryCodes(ctx context.Context, userID int64) ([]*model.RecoveryCode, error) {
+	query := `
+		SELECT id, code_hash
+		FROM user_recovery_codes
+		WHERE user_id = ? AND used_at IS NULL
+	`
+
+	var codes []*model.RecoveryCode
+	err := db.FindAll(ctx, query, &codes, userID)
+	return codes, err
+}
+
+// UseRecoveryCode burns a recovery code, returning false if it was already used
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, id int64) (bool, error) {
+	affected, err := db.Update(ctx, `
+		UPDATE user_recovery_codes SET used_at = NOW() WHERE id = ? AND used_at IS NULL
+	`, id)
+	return affected == 1, err
+}
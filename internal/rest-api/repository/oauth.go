@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type IOAuthRepository interface {
+	CreateClient(ctx context.Context, c *model.OAuthClient, secretHash string) error
+	ListClients(ctx context.Context) ([]*model.OAuthClient, error)
+	FindClient(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	RevokeClient(ctx context.Context, clientID string) error
+	CreateCode(ctx context.Context, codeHash string, c *model.AuthorizationCode) error
+	ConsumeCode(ctx context.Context, codeHash string) (*model.AuthorizationCode, error)
+}
+
+type OAuthRepository struct {
+	db *sql.DB
+}
+
+func NewOAuthRepository(db *sql.DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+const selectOAuthClientQuery = `
+	SELECT id, client_id, secret_hash, name, redirect_uris, scopes, grant_types, revoked_at, created_at
+	FROM oauth_clients
+`
+
+// Lists are stored space separated, the same way OAuth itself encodes scopes
+func (r *OAuthRepository) CreateClient(ctx context.Context, c *model.OAuthClient, secretHash string) error {
+	id, err := db.Insert(ctx, `
+		INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, grant_types)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?, ?)
+	`, c.ClientID, secretHash, c.Name,
+		strings.Join(c.RedirectURIs, " "), strings.Join(c.Scopes, " "), strings.Join(c.GrantTypes, " "))
+	if err != nil {
+		return err
+	}
+	c.ID = id
+	return nil
+}
+
+func (r *OAuthRepository) ListClients(ctx context.Context) ([]*model.OAuthClient, error) {
+	clients := []*model.OAuthClient{}
+	if err := db.FindAll(ctx, selectOAuthClientQuery+" ORDER BY id DESC", &clients); err != nil {
+		return nil, err
+	}
+	for _, c := range clients {
+		expandClient(c)
+	}
+	return clients, nil
+}
+
+// FindClient returns an active client, or ErrNotFound if it does not exist or was revoked
+func (r *OAuthRepository) FindClient(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	var c model.OAuthClient
+	if err := db.FindOne(ctx, selectOAuthClientQuery+" WHERE client_id = ? AND revoked_at IS NULL", &c, clientID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	expandClient(&c)
+	return &c, nil
+}
+
+// RevokeClient disables the client and every refresh token issued to it
+func (r *OAuthRepository) RevokeClient(ctx context.Context, clientID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE oauth_clients SET revoked_at = NOW() WHERE client_id = ? AND revoked_at IS NULL
+	`, clientID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrNotFound
+	}
+
+	if _, err := db.ExecTx(ctx, tx, `
+		UPDATE refresh_tokens SET revoked_at = NOW() WHERE client_id = ? AND revoked_at IS NULL
+	`, clientID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *OAuthRepository) CreateCode(ctx context.Context, codeHash string, c *model.AuthorizationCode) error {
+	id, err := db.Insert(ctx, `
+		INSERT INTO oauth_authorization_codes
+			(code_hash, client_id, user_id, redirect_uri, scope, code_challenge, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, codeHash, c.ClientID, c.UserID, c.RedirectURI, c.Scope, c.CodeChallenge, c.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	c.ID = id
+	return nil
+}
+
+// ConsumeCode burns an unexpired code and returns it. A code can only be
+// redeemed once, even by concurrent requests; anything else is ErrInvalidCode.
+func (r *OAuthRepository) ConsumeCode(ctx context.Context, codeHash string) (*model.AuthorizationCode, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE oauth_authorization_codes
+		SET used_at = NOW()
+		WHERE code_hash = ? AND used_at IS NULL AND expires_at > NOW()
+	`, codeHash)
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, apperr.ErrInvalidCode
+	}
+
+	var c model.AuthorizationCode
+	if err := db.FindOneTx(ctx, tx, `
+		SELECT id, client_id, user_id, redirect_uri, scope, code_challenge, expires_at
+		FROM oauth_authorization_codes
+		WHERE code_hash = ?
+	`, &c, codeHash); err != nil {
+		return nil, err
+	}
+
+	return &c, tx.Commit()
+}
+
+func expandClient(c *model.OAuthClient) {
+	c.RedirectURIs = strings.Fields(c.RedirectList)
+	c.Scopes = strings.Fields(c.ScopeList)
+	c.GrantTypes = strings.Fields(c.GrantTypeList)
+	c.Confidential = c.SecretHash != nil
+}
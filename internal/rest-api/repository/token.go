@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type ITokenRepository interface {
+	Create(ctx context.Context, t *model.RefreshToken) error
+	FindByTokenID(ctx context.Context, tokenID string) (*model.RefreshToken, error)
+	Rotate(ctx context.Context, oldTokenID string, next *model.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+	ListSessions(ctx context.Context, userID int64) ([]*model.Session, error)
+	RevokeSession(ctx context.Context, userID int64, sessionID string) error
+	CreateTx(ctx context.Context, tx *sql.Tx, t *model.RefreshToken) error
+	RevokeFamilyTx(ctx context.Context, tx *sql.Tx, familyID string) error
+	RevokeAllForUserTx(ctx context.Context, tx *sql.Tx, userID int64) error
+}
+
+const insertRefreshTokenQuery = `
+	INSERT INTO refresh_tokens (token_id, family_id, user_id, user_agent, ip_address, client_id, scope, expires_at)
+	VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)
+`
+
+// createRefreshTokenTx stores a refresh token inside an existing transaction
+func createRefreshTokenTx(ctx context.Context, tx *sql.Tx, t *model.RefreshToken) error {
+	id, err := db.InsertTx(ctx, tx, insertRefreshTokenQuery, t.TokenID, t.FamilyID, t.UserID, t.UserAgent, t.IPAddress, t.ClientID, t.Scope, t.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	t.ID = id
+	return nil
+}
+
+type TokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+func (r *TokenRepository) Create(ctx context.Context, t *model.RefreshToken) error {
+	id, err := db.Insert(ctx, insertRefreshTokenQuery, t.TokenID, t.FamilyID, t.UserID, t.UserAgent, t.IPAddress, t.ClientID, t.Scope, t.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	t.ID = id
+	return nil
+}
+
+func (r *TokenRepository) FindByTokenID(ctx context.Context, tokenID string) (*model.RefreshToken, error) {
+	query := `
+		SELECT id, token_id, family_id, user_id, replaced_by,
+			COALESCE(client_id, '') AS client_id, COALESCE(scope, '') AS scope,
+			expires_at, used_at, revoked_at, created_at
+		FROM refresh_tokens
+		WHERE token_id = ?
+		LIMIT 1
+	`
+
+	var t model.RefreshToken
+	if err := db.FindOne(ctx, query, &t, tokenID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrInvalidToken
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Rotate marks oldTokenID as used and stores its replacement in one Tx.
+// If the old token was already used or revoked (e.g. two concurrent
+// refreshes), nothing is written and ErrTokenReused is returned.
+func (r *TokenRepository) Rotate(ctx context.Context, oldTokenID string, next *model.RefreshToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE refresh_tokens
+		SET used_at = NOW(), replaced_by = ?
+		WHERE token_id = ? AND used_at IS NULL AND revoked_at IS NULL
+	`, next.TokenID, oldTokenID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrTokenReused
+	}
+
+	if err := createRefreshTokenTx(ctx, tx, next); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeFamily revokes every token that descends from the same login
+func (r *TokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := db.Update(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = ? AND revoked_at IS NULL
+	`, familyID)
+	return err
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of the user
+func (r *TokenRepository) RevokeAllForUser(ctx context.Context, userID int64) error {
+	_, err := db.Update(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = ? AND revoked_at IS NULL
+	`, userID)
+	return err
+}
+
+// ListSessions returns one row per active token family, i.e. per logged-in device.
+// The live (unused) token of a family carries the latest device info.
+func (r *TokenRepository) ListSessions(ctx context.Context, userID int64) ([]*model.Session, error) {
+	query := `
+		SELECT
+			t.family_id AS id,
+			COALESCE(t.user_agent, '') AS user_agent,
+			COALESCE(t.ip_address, '') AS ip_address,
+			COALESCE(t.client_id, '') AS client_id,
+			(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id) AS created_at,
+			t.created_at AS last_used_at,
+			t.expires_at
+		FROM refresh_tokens t
+		WHERE t.user_id = ?
+			AND t.used_at IS NULL
+			AND t.revoked_at IS NULL
+			AND t.expires_at > NOW()
+		ORDER BY t.created_at DESC
+	`
+
+	sessions := []*model.Session{}
+	err := db.FindAll(ctx, query, &sessions, userID)
+	return sessions, err
+}
+
+// RevokeSession revokes a session of the user, returning ErrNotFound if the
+// session does not exist, belongs to someone else or is already revoked.
+func (r *TokenRepository) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	affected, err := db.Update(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = ? AND user_id = ? AND revoked_at IS NULL
+	`, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrNotFound
+	}
+	return nil
+}
+
+// CreateTx stores a refresh token inside a transaction run by another repository
+func (r *TokenRepository) CreateTx(ctx context.Context, tx *sql.Tx, t *model.RefreshToken) error {
+	return createRefreshTokenTx(ctx, tx, t)
+}
+
+// RevokeFamilyTx is RevokeFamily inside an existing transaction
+func (r *TokenRepository) RevokeFamilyTx(ctx context.Context, tx *sql.Tx, familyID string) error {
+	_, err := db.ExecTx(ctx, tx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE family_id = ? AND revoked_at IS NULL
+	`, familyID)
+	return err
+}
+
+// RevokeAllForUserTx is RevokeAllForUser inside an existing transaction
+func (r *TokenRepository) RevokeAllForUserTx(ctx context.Context, tx *sql.Tx, userID int64) error {
+	_, err := db.ExecTx(ctx, tx, `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = ? AND revoked_at IS NULL
+	`, userID)
+	return err
+}
@@ -3,15 +3,37 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
 	"github.com/lakhan-purohit/net-http/internal/pkg/db"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
 )
 
 type IUserRepository interface {
-	GetList(ctx context.Context, limit, offset int) ([]*model.User, error)
+	IPasswordHistory
+	GetList(ctx context.Context, f *model.UserFilter) ([]*model.User, error)
+	CountList(ctx context.Context, f *model.UserFilter) (int64, error)
 	GetStatsForUsers(ctx context.Context, userIDs []int64) (map[int64]*model.UserStats, error)
 	WithTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error
+	FindByUUID(ctx context.Context, uuid string) (*model.User, error)
+	Unlock(ctx context.Context, userID int64) error
+	PasswordHash(ctx context.Context, userID int64) (string, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	UpdatePasswordTx(ctx context.Context, tx *sql.Tx, userID int64, passwordHash string) error
+	UpdateEmailTx(ctx context.Context, tx *sql.Tx, userID int64, email string) error
+	UpdateProfile(ctx context.Context, userID int64, username, avatar string) error
+	Delete(ctx context.Context, userID int64) error
+	FindDeletedByUUID(ctx context.Context, uuid string) (*model.User, error)
+	Restore(ctx context.Context, userID int64) error
+	PurgeDeleted(ctx context.Context, before time.Time, limit int) ([]*model.User, error)
+	SetStatusTx(ctx context.Context, tx *sql.Tx, userID int64, from, to int, until *time.Time) error
+	AddStatusChangeTx(ctx context.Context, tx *sql.Tx, c *model.StatusChange) error
+	StatusHistory(ctx context.Context, userID int64) ([]*model.StatusChange, error)
+	ExpireStatuses(ctx context.Context, limit int) ([]*model.User, error)
 }
 
 type UserRepository struct {
@@ -44,20 +66,135 @@ func ScanUser(rows *sql.Rows) (*model.User, error) {
 	return u, nil
 }
 
+// GetList returns the page of users selected by f, in list order. Every
+// value is bound as a parameter; sort fields are checked against
+// UserSortFields.
 func (r *UserRepository) GetList(
 	ctx context.Context,
-	limit, offset int,
+	f *model.UserFilter,
 ) ([]*model.User, error) {
 
-	query := `
-		SELECT uuid, id, username, email, status
-		FROM users
-		LIMIT ? OFFSET ?
-	`
+	where, args := userListWhere(f)
+	sort := f.Sort
+
+	if c := f.Cursor; c != nil {
+		// Rows strictly beyond the position in the direction being read
+		op := ">"
+		if strings.HasPrefix(sort[0], "-") != c.Backward {
+			op = "<"
+		}
+		where = append(where, "(created_at "+op+" ? OR (created_at = ? AND id "+op+" ?))")
+		args = append(args, c.CreatedAt, c.CreatedAt, c.ID)
+
+		if c.Backward {
+			// Read towards the start, nearest rows first
+			sort = reverseSort(sort)
+		}
+	}
+
+	query := "SELECT uuid, id, username, email, status, status_until, role, created_at FROM users WHERE " + strings.Join(where, " AND ")
+	query += " ORDER BY " + userOrderBy(sort) + " LIMIT ? OFFSET ?"
+	args = append(args, f.Limit, f.Offset)
 
 	var users []*model.User
-	err := db.FindAll(ctx, query, &users, limit, offset)
-	return users, err
+	if err := db.FindAll(ctx, query, &users, args...); err != nil {
+		return nil, err
+	}
+
+	if f.Cursor != nil && f.Cursor.Backward {
+		slices.Reverse(users)
+	}
+	return users, nil
+}
+
+// CountList counts the users matching f, ignoring its cursor and page
+func (r *UserRepository) CountList(ctx context.Context, f *model.UserFilter) (int64, error) {
+	var result struct {
+		Count int64 `db:"count"`
+	}
+
+	where, args := userListWhere(f)
+	query := "SELECT COUNT(*) AS count FROM users WHERE " + strings.Join(where, " AND ")
+
+	if err := db.FindOne(ctx, query, &result, args...); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// userListWhere turns the filters of f into WHERE conditions and their
+// arguments. Deleted users are always left out.
+func userListWhere(f *model.UserFilter) ([]string, []any) {
+	where := []string{"deleted_at IS NULL"}
+	var args []any
+	if f.Status != nil {
+		where = append(where, "status = ?")
+		args = append(args, *f.Status)
+	}
+	if f.Email != "" {
+		where = append(where, "email LIKE ?")
+		args = append(args, db.EscapeLike(f.Email)+"%")
+	}
+	if f.CreatedFrom != nil {
+		where = append(where, "created_at >= ?")
+		args = append(args, *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		where = append(where, "created_at < ?")
+		args = append(args, *f.CreatedTo)
+	}
+	if terms := db.FulltextPrefixTerms(f.Query); terms != "" {
+		where = append(where, "MATCH(username, email) AGAINST (? IN BOOLEAN MODE)")
+		args = append(args, terms)
+	}
+	return where, args
+}
+
+// UserSortFields are the fields user lists can be sorted by; each is also the
+// column name
+var UserSortFields = []string{"created_at", "username", "email", "status"}
+
+// UserCursorSort reports whether a user list in this order can be paged by
+// cursor, which needs the (created_at, id) order
+func UserCursorSort(sort []string) bool {
+	return len(sort) == 1 && strings.TrimPrefix(sort[0], "-") == "created_at"
+}
+
+// userOrderBy builds an ORDER BY clause from sort fields such as
+// "-created_at". Only whitelisted names reach the SQL, and id always breaks
+// ties so pages never overlap.
+func userOrderBy(sort []string) string {
+	if len(sort) == 0 {
+		sort = []string{"-created_at"}
+	}
+
+	var terms []string
+	dir := "ASC"
+	for _, field := range sort {
+		name := strings.TrimPrefix(field, "-")
+		i := slices.Index(UserSortFields, name)
+		if i < 0 {
+			continue
+		}
+		dir = "ASC"
+		if strings.HasPrefix(field, "-") {
+			dir = "DESC"
+		}
+		terms = append(terms, UserSortFields[i]+" "+dir)
+	}
+	return strings.Join(append(terms, "id "+dir), ", ")
+}
+
+func reverseSort(sort []string) []string {
+	reversed := make([]string, len(sort))
+	for i, field := range sort {
+		if name, ok := strings.CutPrefix(field, "-"); ok {
+			reversed[i] = name
+		} else {
+			reversed[i] = "-" + field
+		}
+	}
+	return reversed
 }
 
 // GetStatsForUsers demonstrates a "Scalable" way to fetch related data for a list of items
@@ -82,9 +219,7 @@ func (r *UserRepository) GetStatsForUsers(ctx context.Context, userIDs []int64)
 	// 2. Fetch using our generic FindAll
 	var stats []*model.UserStats
 	if err := db.FindAll(ctx, query, &stats, args...); err != nil {
-		// If table doesn't exist, we'll return mock data for the demo, 
-		// but in a real app, this would be a real table query.
-		return r.getMockStats(userIDs), nil
+		return nil, err
 	}
 
 	// 3. Map to result
@@ -96,13 +231,249 @@ func (r *UserRepository) GetStatsForUsers(ctx context.Context, userIDs []int64)
 	return statsMap, nil
 }
 
-func (r *UserRepository) getMockStats(userIDs []int64) map[int64]*model.UserStats {
-	statsMap := make(map[int64]*model.UserStats)
-	for _, id := range userIDs {
-		statsMap[id] = &model.UserStats{
-			UserID:     id,
-			LoginCount: int(id * 5),
+func (r *UserRepository) FindByUUID(ctx context.Context, uuid string) (*model.User, error) {
+	query := `
+		SELECT uuid, id, username, email, status, status_until, role, COALESCE(avatar, '') AS avatar
+		FROM users
+		WHERE uuid = ? AND deleted_at IS NULL
+		LIMIT 1
+	`
+
+	var user model.User
+	if err := db.FindOne(ctx, query, &user, uuid); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Unlock lifts a brute-force lockout
+func (r *UserRepository) Unlock(ctx context.Context, userID int64) error {
+	_, err := db.Update(ctx, "UPDATE users SET locked_until = NULL WHERE id = ? AND deleted_at IS NULL", userID)
+	return err
+}
+
+// PasswordHash returns the stored hash of the user's password
+func (r *UserRepository) PasswordHash(ctx context.Context, userID int64) (string, error) {
+	var result struct {
+		Password string `db:"password"`
+	}
+	if err := db.FindOne(ctx, "SELECT password FROM users WHERE id = ? AND deleted_at IS NULL LIMIT 1", &result, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperr.ErrNotFound
+		}
+		return "", err
+	}
+	return result.Password, nil
+}
+
+func (r *UserRepository) PasswordHistory(ctx context.Context, userID int64, limit int) ([]string, error) {
+	return findPasswordHistory(ctx, userID, limit)
+}
+
+// EmailExists also counts deleted accounts: they keep their address until
+// they are purged, so it stays unique if they are restored
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var result struct {
+		Exists bool `db:"email_exists"`
+	}
+	err := db.FindOne(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = ?) AS email_exists", &result, email)
+	return result.Exists, err
+}
+
+// UpdatePasswordTx sets a new password hash, keeping the old one in the history
+func (r *UserRepository) UpdatePasswordTx(ctx context.Context, tx *sql.Tx, userID int64, passwordHash string) error {
+	return setPasswordTx(ctx, tx, userID, passwordHash)
+}
+
+// UpdateEmailTx switches the user to a new address, returning ErrEmailTaken
+// if another account got it first
+func (r *UserRepository) UpdateEmailTx(ctx context.Context, tx *sql.Tx, userID int64, email string) error {
+	_, err := db.ExecTx(ctx, tx, "UPDATE users SET email = ? WHERE id = ? AND deleted_at IS NULL", email, userID)
+	if db.IsDuplicateKey(err) {
+		return apperr.ErrEmailTaken
+	}
+	return err
+}
+
+// UpdateProfile changes the given profile fields; empty values are left as they are
+func (r *UserRepository) UpdateProfile(ctx context.Context, userID int64, username, avatar string) error {
+	_, err := db.Update(ctx, `
+		UPDATE users
+		SET username = COALESCE(NULLIF(?, ''), username),
+			avatar = COALESCE(NULLIF(?, ''), avatar)
+		WHERE id = ? AND deleted_at IS NULL
+	`, username, avatar, userID)
+	return err
+}
+
+// Delete soft-deletes the user: the account disappears from every query but
+// can be restored until PurgeDeleted removes it for good
+func (r *UserRepository) Delete(ctx context.Context, userID int64) error {
+	affected, err := db.Update(ctx, "UPDATE users SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL", userID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrNotFound
+	}
+	return nil
+}
+
+// FindDeletedByUUID looks up a soft-deleted user that has not been purged yet
+func (r *UserRepository) FindDeletedByUUID(ctx context.Context, uuid string) (*model.User, error) {
+	query := `
+		SELECT uuid, id, username, email, status, status_until, role, COALESCE(avatar, '') AS avatar, deleted_at
+		FROM users
+		WHERE uuid = ? AND deleted_at IS NOT NULL
+		LIMIT 1
+	`
+
+	var user model.User
+	if err := db.FindOne(ctx, query, &user, uuid); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Restore undoes Delete
+func (r *UserRepository) Restore(ctx context.Context, userID int64) error {
+	affected, err := db.Update(ctx, "UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", userID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted hard-deletes up to limit users soft-deleted before the given
+// time and returns them, so their files can be removed too. Dependent rows go
+// with them via ON DELETE CASCADE. A user restored meanwhile is left alone.
+func (r *UserRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) ([]*model.User, error) {
+	query := `
+		SELECT uuid, id, username, email, COALESCE(avatar, '') AS avatar, deleted_at
+		FROM users
+		WHERE deleted_at < ?
+		ORDER BY deleted_at
+		LIMIT ?
+	`
+
+	var candidates []*model.User
+	if err := db.FindAll(ctx, query, &candidates, before, limit); err != nil {
+		return nil, err
+	}
+
+	var purged []*model.User
+	for _, u := range candidates {
+		affected, err := db.Delete(ctx, "DELETE FROM users WHERE id = ? AND deleted_at < ?", u.ID, before)
+		if err != nil {
+			return purged, err
+		}
+		if affected == 1 {
+			purged = append(purged, u)
+		}
+	}
+	return purged, nil
+}
+
+// SetStatusTx moves the user from one status to another; until is when the
+// new status ends by itself, or nil. It fails with ErrInvalidStatusTransition
+// if the status changed in the meantime.
+func (r *UserRepository) SetStatusTx(ctx context.Context, tx *sql.Tx, userID int64, from, to int, until *time.Time) error {
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE users
+		SET status = ?, status_until = ?
+		WHERE id = ? AND status = ? AND deleted_at IS NULL
+	`, to, until, userID, from)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrInvalidStatusTransition
+	}
+	return nil
+}
+
+// AddStatusChangeTx appends to the user's status history
+func (r *UserRepository) AddStatusChangeTx(ctx context.Context, tx *sql.Tx, c *model.StatusChange) error {
+	id, err := db.InsertTx(ctx, tx, `
+		INSERT INTO user_status_history (user_id, from_status, to_status, reason, expires_at, changed_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, c.UserID, c.FromStatus, c.ToStatus, c.Reason, c.ExpiresAt, c.ChangedByID)
+	if err != nil {
+		return err
+	}
+	c.ID = id
+	return nil
+}
+
+// StatusHistory returns the user's status changes, newest first
+func (r *UserRepository) StatusHistory(ctx context.Context, userID int64) ([]*model.StatusChange, error) {
+	query := `
+		SELECT h.id, h.user_id, h.from_status, h.to_status, h.reason, h.expires_at,
+			h.changed_by, COALESCE(a.uuid, '') AS changed_by_uuid, h.created_at
+		FROM user_status_history h
+		LEFT JOIN users a ON a.id = h.changed_by
+		WHERE h.user_id = ?
+		ORDER BY h.id DESC
+	`
+
+	var history []*model.StatusChange
+	err := db.FindAll(ctx, query, &history, userID)
+	return history, err
+}
+
+// ExpireStatuses makes up to limit users whose temporary ban or suspension
+// has run out active again, records it in their history and returns them
+func (r *UserRepository) ExpireStatuses(ctx context.Context, limit int) ([]*model.User, error) {
+	query := `
+		SELECT uuid, id, status, status_until
+		FROM users
+		WHERE status_until <= NOW() AND deleted_at IS NULL
+		ORDER BY status_until
+		LIMIT ?
+	`
+
+	var candidates []*model.User
+	if err := db.FindAll(ctx, query, &candidates, limit); err != nil {
+		return nil, err
+	}
+
+	var expired []*model.User
+	for _, u := range candidates {
+		var changed bool
+		err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
+			// An admin may have changed the status since it was read
+			affected, err := db.ExecTx(ctx, tx, `
+				UPDATE users
+				SET status = ?, status_until = NULL
+				WHERE id = ? AND status = ? AND status_until <= NOW()
+			`, constants.UserStatusActive, u.ID, u.Status)
+			if err != nil || affected == 0 {
+				return err
+			}
+
+			changed = true
+			return r.AddStatusChangeTx(ctx, tx, &model.StatusChange{
+				UserID:     u.ID,
+				FromStatus: u.Status,
+				ToStatus:   constants.UserStatusActive,
+				Reason:     "Temporary status expired",
+			})
+		})
+		if err != nil {
+			return expired, err
+		}
+		if changed {
+			expired = append(expired, u)
 		}
 	}
-	return statsMap
+	return expired, nil
 }
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/db"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+)
+
+type IUserTokenRepository interface {
+	Create(ctx context.Context, t *model.UserToken) error
+	FindActive(ctx context.Context, userID int64, purpose string) (*model.UserToken, error)
+	ClaimAttempt(ctx context.Context, id int64, max int) (bool, error)
+	Invalidate(ctx context.Context, id int64) error
+	Consume(ctx context.Context, id int64) error
+	CountSince(ctx context.Context, userID int64, purpose string, since time.Time) (int, error)
+	ConsumeTx(ctx context.Context, tx *sql.Tx, id int64) error
+}
+
+type UserTokenRepository struct {
+	db *sql.DB
+}
+
+func NewUserTokenRepository(db *sql.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+// Create stores a new token and invalidates any outstanding token of the same
+// user and purpose, so only the most recently sent code works.
+func (r *UserTokenRepository) Create(ctx context.Context, t *model.UserToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = db.ExecTx(ctx, tx, `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE user_id = ? AND purpose = ? AND used_at IS NULL
+	`, t.UserID, t.Purpose)
+	if err != nil {
+		return err
+	}
+
+	id, err := db.InsertTx(ctx, tx, `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, target, expires_at)
+		VALUES (?, ?, ?, NULLIF(?, ''), ?)
+	`, t.UserID, t.Purpose, t.TokenHash, t.Target, t.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	t.ID = id
+
+	return tx.Commit()
+}
+
+// FindActive returns the latest unused, unexpired token of the user for purpose
+func (r *UserTokenRepository) FindActive(ctx context.Context, userID int64, purpose string) (*model.UserToken, error) {
+	query := `
+		SELECT id, user_id, purpose, token_hash, COALESCE(target, '') AS target,
+			attempts, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > NOW()
+		ORDER BY id DESC
+		LIMIT 1
+	`
+
+	var t model.UserToken
+	if err := db.FindOne(ctx, query, &t, userID, purpose); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ClaimAttempt counts one guess against the token before the code is compared.
+// It reports false once max guesses were made or the token was used, so
+// concurrent guesses cannot all pass on the same attempt count.
+func (r *UserTokenRepository) ClaimAttempt(ctx context.Context, id int64, max int) (bool, error) {
+	affected, err := db.Update(ctx, `
+		UPDATE user_tokens
+		SET attempts = attempts + 1
+		WHERE id = ? AND used_at IS NULL AND attempts < ?
+	`, id, max)
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Invalidate burns a token without it having been used successfully
+func (r *UserTokenRepository) Invalidate(ctx context.Context, id int64) error {
+	_, err := db.Update(ctx, "UPDATE user_tokens SET used_at = NOW() WHERE id = ? AND used_at IS NULL", id)
+	return err
+}
+
+// Consume redeems a token on its own, failing with ErrInvalidToken if it was
+// already used or has expired
+func (r *UserTokenRepository) Consume(ctx context.Context, id int64) error {
+	affected, err := db.Update(ctx, `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE id = ? AND used_at IS NULL AND expires_at > NOW()
+	`, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrInvalidToken
+	}
+	return nil
+}
+
+// CountSince counts the tokens issued to the user for purpose since the given time
+func (r *UserTokenRepository) CountSince(ctx context.Context, userID int64, purpose string, since time.Time) (int, error) {
+	var result struct {
+		Count int `db:"count"`
+	}
+
+	query := `
+		SELECT COUNT(*) AS count
+		FROM user_tokens
+		WHERE user_id = ? AND purpose = ? AND created_at > ?
+	`
+	if err := db.FindOne(ctx, query, &result, userID, purpose, since); err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// ConsumeTx redeems a token inside a transaction run by another repository
+func (r *UserTokenRepository) ConsumeTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	return consumeUserTokenTx(ctx, tx, id)
+}
+
+// consumeUserTokenTx marks a token as used inside a transaction. It fails with
+// ErrInvalidToken if the token was already used, so a code can never be
+// redeemed twice even by concurrent requests.
+func consumeUserTokenTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	affected, err := db.ExecTx(ctx, tx, `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE id = ? AND used_at IS NULL AND expires_at > NOW()
+	`, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return apperr.ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package schema
+
+import "time"
+
+type ImpersonateRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500" example:"Reproducing ticket #4521"`
+}
+
+type RestoreUserRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=500" example:"Deleted by mistake, see ticket #4630"`
+}
+
+// ChangeUserStatusRequest moves an account to another status (see
+// constants.UserStatus*). Pending cannot be set; ExpiresAt is only accepted
+// for suspended and banned, which then end by themselves.
+type ChangeUserStatusRequest struct {
+	Status    *int       `json:"status" validate:"required,oneof=0 1 3 4" example:"4"`
+	Reason    string     `json:"reason" validate:"required,min=5,max=500" example:"Spam reports, see ticket #4711"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2026-01-31T00:00:00Z"`
+}
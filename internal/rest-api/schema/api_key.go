@@ -0,0 +1,8 @@
+package schema
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100" example:"billing-service"`
+	OwnerUUID     string   `json:"owner_uuid" validate:"omitempty,uuid" example:"550e8400-e29b-41d4-a716-446655440000"` // defaults to the caller
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=* auth user admin" example:"user"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=3650" example:"90"` // omit for a non-expiring key
+}
@@ -10,9 +10,55 @@ type LoginRequest struct {
 type SignUpRequest struct {
 	Username string `json:"username" form:"username" validate:"required,min=3,max=30" example:"johndoe"`
 	Email    string `json:"email" form:"email" validate:"required,email" example:"john@example.com"`
-	Password string `json:"password" form:"password" validate:"required,min=6" example:"password123"`
+	Password string `json:"password" form:"password" validate:"required" example:"correct-horse-battery"` // see password.Policy
 
 	Avatar       *multipart.FileHeader `file:"avatar"`
 	LicenseFront *multipart.FileHeader `file:"license_front"`
 	LicenseBack  *multipart.FileHeader `file:"license_back"`
 }
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
+}
+
+type ResetPasswordRequest struct {
+	Email    string `json:"email" validate:"required,email" example:"john@example.com"`
+	Code     string `json:"code" validate:"required,numeric,len=6" example:"123456"`
+	Password string `json:"password" validate:"required" example:"new-correct-horse-battery"` // see password.Policy
+}
+
+type VerifyEmailRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
+	Code  string `json:"code" validate:"required,numeric,len=6" example:"123456"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
+}
+
+type MFAConfirmRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6" example:"123456"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	Code     string `json:"code" validate:"required,max=20" example:"123456"` // TOTP code or recovery code
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
+}
+
+type MagicLinkConsumeRequest struct {
+	Email string `json:"email" validate:"required,email" example:"john@example.com"`
+	Token string `json:"token" validate:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required,max=2000" example:"SplxlOBeZQQYbYS6WxSbIA"`
+	State string `json:"state" validate:"required,len=64,hexadecimal" example:"3f1c..."`
+}
@@ -0,0 +1,26 @@
+package schema
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100" example:"Partner App"`
+	RedirectURIs []string `json:"redirect_uris" validate:"omitempty,dive,url,max=2000" example:"https://partner.example.com/callback"`
+	Scopes       []string `json:"scopes" validate:"required,dive,oneof=profile email offline_access" example:"profile"`
+	GrantTypes   []string `json:"grant_types" validate:"required,dive,oneof=authorization_code refresh_token client_credentials" example:"authorization_code"`
+	Confidential bool     `json:"confidential" example:"true"` // confidential clients get a secret; public (SPA/mobile) clients rely on PKCE alone
+}
+
+// AuthorizeRequest carries the RFC 6749 authorization request parameters.
+// Only response_type=code with S256 PKCE is supported.
+type AuthorizeRequest struct {
+	ResponseType        string `json:"response_type" query:"response_type" validate:"required,eq=code" example:"code"`
+	ClientID            string `json:"client_id" query:"client_id" validate:"required,max=64" example:"c_5f2b9e0d7a61"`
+	RedirectURI         string `json:"redirect_uri" query:"redirect_uri" validate:"required,url,max=2000" example:"https://partner.example.com/callback"`
+	Scope               string `json:"scope" query:"scope" validate:"max=500" example:"profile offline_access"`
+	State               string `json:"state" query:"state" validate:"max=500" example:"xyz"`
+	CodeChallenge       string `json:"code_challenge" query:"code_challenge" validate:"required,min=43,max=128" example:"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"`
+	CodeChallengeMethod string `json:"code_challenge_method" query:"code_challenge_method" validate:"required,eq=S256" example:"S256"`
+}
+
+type AuthorizeDecisionRequest struct {
+	AuthorizeRequest
+	Approve bool `json:"approve" example:"true"`
+}
@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"mime/multipart"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required,max=1024" example:"password123"`
+	NewPassword     string `json:"new_password" validate:"required" example:"correct-horse-battery"` // see password.Policy
+}
+
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" validate:"required,email,max=255" example:"john.doe@example.com"`
+	CurrentPassword string `json:"current_password" validate:"required,max=1024" example:"password123"`
+}
+
+type ChangeEmailConfirmRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6" example:"123456"`
+}
+
+// UpdateUserRequest is sent as JSON, or as multipart/form-data to replace the
+// avatar. Omitted fields keep their value.
+type UpdateUserRequest struct {
+	Username string `json:"username" form:"username" validate:"omitempty,min=3,max=30" example:"johndoe"`
+
+	Avatar *multipart.FileHeader `json:"-" file:"avatar"`
+}
+
+// UserListQuery holds the query parameters of the user lists
+type UserListQuery struct {
+	request.PaginationRequest
+
+	Sort        string     `query:"sort" validate:"omitempty,max=200"` // see repository.UserSortFields
+	Status      *int       `query:"status" validate:"omitempty,oneof=0 1 2 3 4"`
+	Email       string     `query:"email" validate:"omitempty,max=255"`
+	CreatedFrom *time.Time `query:"created_from"`
+	CreatedTo   *time.Time `query:"created_to"`
+	Q           string     `query:"q" validate:"omitempty,max=100"`
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/lockout"
+	"github.com/lakhan-purohit/net-http/internal/pkg/mailer"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Change password
+// @Description Sets a new password after checking the current one. Every other session is logged out; the response carries a fresh token pair for this one.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} response.LoginResponse
+// @Failure 400 {object} response.PasswordPolicyErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Failure 429 {object} response.ErrorResponse "Too many wrong passwords; see Retry-After"
+// @Router /api/v1/private/user/change-password [post]
+func ChangePasswordHandler(users repository.IUserRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		var req schema.ChangePasswordRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := users.FindByUUID(r.Context(), claims.UUID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if !checkCurrentPassword(w, r, users, user.ID, user.Email, req.CurrentPassword) {
+			return
+		}
+
+		if err := checkPasswordPolicy(r.Context(), users, user, req.NewPassword); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		passwordHash, err := utils.HashPassword(req.NewPassword)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// Taken before signing so the new access token survives the cutoff
+		cutoff := time.Now().Truncate(time.Second)
+		pair, session, err := newSession(r, user, claims.SessionID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		err = users.WithTransaction(r.Context(), func(tx *sql.Tx) error {
+			if err := users.UpdatePasswordTx(r.Context(), tx, user.ID, passwordHash); err != nil {
+				return err
+			}
+			if err := tokens.RevokeAllForUserTx(r.Context(), tx, user.ID); err != nil {
+				return err
+			}
+			return tokens.CreateTx(r.Context(), tx, session)
+		})
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := revokeAccessTokens(r.Context(), user.ID, cutoff); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		user.Token = pair.AccessToken
+		user.RefreshToken = pair.RefreshToken
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Password changed, other sessions have been logged out",
+			Data:    user,
+		})
+	}
+}
+
+// @Summary Request an email change
+// @Description Sends a code to the new address after checking the current password. The address only changes once the code is confirmed.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.ChangeEmailRequest true "New email and current password"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Failure 409 {object} response.ErrorResponse "Email already in use"
+// @Failure 429 {object} response.ErrorResponse "Too many wrong passwords; see Retry-After"
+// @Router /api/v1/private/user/change-email [post]
+func ChangeEmailHandler(users repository.IUserRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		var req schema.ChangeEmailRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := users.FindByUUID(r.Context(), claims.UUID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if strings.EqualFold(req.NewEmail, user.Email) {
+			response.Error(w, apperr.ErrEmailUnchanged)
+			return
+		}
+
+		if !checkCurrentPassword(w, r, users, user.ID, user.Email, req.CurrentPassword) {
+			return
+		}
+
+		taken, err := users.EmailExists(r.Context(), req.NewEmail)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if taken {
+			response.Error(w, apperr.ErrEmailTaken)
+			return
+		}
+
+		ttl := config.Get().Auth.EmailChangeTTL
+		code, err := issueOTP(r.Context(), userTokens, user.ID, constants.TokenPurposeEmailChange, req.NewEmail, ttl)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		deliver(mailer.Message{
+			To:      req.NewEmail,
+			Subject: "Confirm your new email address",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nYour code to confirm this address is %s. It expires in %s.\n\nIf you did not ask to change your email you can ignore this email.\n",
+				user.Username, code, ttl,
+			),
+		})
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "A confirmation code has been sent to the new address",
+		})
+	}
+}
+
+// @Summary Confirm an email change
+// @Description Switches the account to the address the code was sent to and notifies the old address. Access tokens carrying the old email stop working; the response carries a fresh token pair for this session.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.ChangeEmailConfirmRequest true "Code sent to the new address"
+// @Success 200 {object} response.LoginResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Failure 409 {object} response.ErrorResponse "Email already in use"
+// @Router /api/v1/private/user/change-email/confirm [post]
+func ChangeEmailConfirmHandler(
+	users repository.IUserRepository,
+	userTokens repository.IUserTokenRepository,
+	tokens repository.ITokenRepository,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		var req schema.ChangeEmailConfirmRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := users.FindByUUID(r.Context(), claims.UUID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		t, err := verifyOTP(r.Context(), userTokens, user.ID, constants.TokenPurposeEmailChange, req.Code)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		oldEmail := user.Email
+		user.Email = t.Target
+
+		// Taken before signing so the new access token survives the cutoff
+		cutoff := time.Now().Truncate(time.Second)
+		pair, session, err := newSession(r, user, claims.SessionID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		err = users.WithTransaction(r.Context(), func(tx *sql.Tx) error {
+			if err := userTokens.ConsumeTx(r.Context(), tx, t.ID); err != nil {
+				if err == apperr.ErrInvalidToken {
+					err = apperr.ErrInvalidCode
+				}
+				return err
+			}
+			if err := users.UpdateEmailTx(r.Context(), tx, user.ID, user.Email); err != nil {
+				return err
+			}
+			// Rotate this session onto a refresh token for the new claims
+			if err := tokens.RevokeFamilyTx(r.Context(), tx, session.FamilyID); err != nil {
+				return err
+			}
+			return tokens.CreateTx(r.Context(), tx, session)
+		})
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// Other sessions keep their refresh tokens and pick up the new email on refresh
+		if err := revokeAccessTokens(r.Context(), user.ID, cutoff); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		deliver(mailer.Message{
+			To:      oldEmail,
+			Subject: "Your email address was changed",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nThe email address of your account was changed to %s. If you did not do this, contact support immediately.\n",
+				user.Username, user.Email,
+			),
+		})
+
+		user.Token = pair.AccessToken
+		user.RefreshToken = pair.RefreshToken
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Email address changed",
+			Data:    user,
+		})
+	}
+}
+
+// checkCurrentPassword re-authenticates a logged-in user before a credential
+// change. Wrong guesses count towards the same backoff as failed logins, so a
+// stolen access token cannot be used to brute-force the password. It writes
+// the error response and returns false when the request must stop.
+func checkCurrentPassword(w http.ResponseWriter, r *http.Request, users repository.IUserRepository, userID int64, email, plain string) bool {
+	guard := lockout.Default()
+	ip := request.ClientIP(r)
+	requestID, _ := r.Context().Value(constants.RequestIDContextKey).(string)
+
+	wait, err := guard.Check(r.Context(), email, ip)
+	if err != nil {
+		response.Error(w, err)
+		return false
+	}
+	if wait > 0 {
+		retryLater(w, apperr.ErrLoginThrottled, wait)
+		return false
+	}
+
+	hash, err := users.PasswordHash(r.Context(), userID)
+	if err != nil {
+		response.Error(w, err)
+		return false
+	}
+
+	if !utils.ComparePassword(hash, plain) {
+		if _, err := guard.Fail(r.Context(), email, ip); err != nil {
+			slog.Error("login_guard_failed", "request_id", requestID, "error", err)
+		}
+		response.Error(w, apperr.ErrInvalidCurrentPassword)
+		return false
+	}
+
+	if err := guard.Succeed(r.Context(), email); err != nil {
+		slog.Error("login_guard_failed", "request_id", requestID, "error", err)
+	}
+	return true
+}
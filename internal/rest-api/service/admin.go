@@ -0,0 +1,220 @@
+package service
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/lockout"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Unlock a user account
+// @Description Lifts a brute-force lockout and clears the failed login counter of the account.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/users/{uuid}/unlock [post]
+func AdminUnlockUserHandler(repo repository.IUserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := repo.FindByUUID(r.Context(), r.PathValue("uuid"))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := repo.Unlock(r.Context(), user.ID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := lockout.Default().Unlock(r.Context(), user.Email); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Account unlocked",
+		})
+	}
+}
+
+// @Summary Impersonate a user
+// @Description Issues a short-lived, non-refreshable access token for the user that also names the calling admin (act claim). Every response to it carries X-Impersonated-By and the grant is written to the audit log. Admins cannot be impersonated.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Param request body schema.ImpersonateRequest true "Why the account is being accessed"
+// @Success 200 {object} response.ImpersonationResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/users/{uuid}/impersonate [post]
+func AdminImpersonateUserHandler(repo repository.IUserRepository, audit repository.IAuditRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		// No chaining: an impersonation token cannot start another one
+		if _, impersonating := middleware.ActorFromContext(r.Context()); impersonating {
+			response.Error(w, apperr.ErrImpersonationNotAllowed)
+			return
+		}
+
+		var req schema.ImpersonateRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := repo.FindByUUID(r.Context(), r.PathValue("uuid"))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if user.ID == claims.UserID || user.Role == constants.RoleAdmin {
+			response.Error(w, apperr.ErrImpersonationNotAllowed)
+			return
+		}
+		if err := checkAccountStatus(user); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		token, tokenID, expiresAt, err := utils.NewJWT().GenerateImpersonation(utils.Claims{
+			UserID: user.ID,
+			Email:  user.Email,
+			Role:   user.Role,
+			UUID:   user.UUID,
+		}, utils.Actor{
+			Subject: claims.UUID,
+			UserID:  claims.UserID,
+			Email:   claims.Email,
+		})
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		requestID, _ := r.Context().Value(constants.RequestIDContextKey).(string)
+		if err := audit.Record(r.Context(), &model.AuditLog{
+			ActorID:      claims.UserID,
+			Action:       constants.AuditActionImpersonate,
+			TargetUserID: user.ID,
+			Reason:       req.Reason,
+			TokenID:      tokenID, // ties the record to everything done with the token
+			IPAddress:    request.ClientIP(r),
+			UserAgent:    truncate(r.UserAgent(), 255),
+			RequestID:    requestID,
+		}); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		slog.Warn("impersonation_started",
+			"request_id", requestID,
+			"actor", claims.UUID,
+			"user", user.UUID,
+			"jti", tokenID,
+			"expires_at", expiresAt,
+		)
+
+		response.Success(response.SendParams{
+			W: w,
+			Data: model.Impersonation{
+				Token:     token,
+				ExpiresAt: expiresAt,
+				User:      *user,
+			},
+		})
+	}
+}
+
+// @Summary Restore a deleted user
+// @Description Undoes the deletion of an account that has not been purged yet. Sessions ended by the deletion stay ended; the user signs in again. The restore is written to the audit log.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Param request body schema.RestoreUserRequest true "Why the account is restored"
+// @Success 200 {object} response.UserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse "No deleted user with this UUID, or already purged"
+// @Router /api/v1/private/admin/users/{uuid}/restore [post]
+func AdminRestoreUserHandler(repo repository.IUserRepository, audit repository.IAuditRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		var req schema.RestoreUserRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := repo.FindDeletedByUUID(r.Context(), r.PathValue("uuid"))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := repo.Restore(r.Context(), user.ID); err != nil {
+			response.Error(w, err)
+			return
+		}
+		user.DeletedAt = nil
+
+		requestID, _ := r.Context().Value(constants.RequestIDContextKey).(string)
+		if err := audit.Record(r.Context(), &model.AuditLog{
+			ActorID:      claims.UserID,
+			Action:       constants.AuditActionRestore,
+			TargetUserID: user.ID,
+			Reason:       req.Reason,
+			IPAddress:    request.ClientIP(r),
+			UserAgent:    truncate(r.UserAgent(), 255),
+			RequestID:    requestID,
+		}); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "User restored",
+			Data:    user,
+		})
+	}
+}
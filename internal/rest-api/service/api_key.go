@@ -0,0 +1,152 @@
+package service
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apikey"
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Create an API key
+// @Description Creates an x-api-key for a user (the caller by default). Scopes name the route groups the key may call: auth, user, admin or * for all. The plaintext key is only returned here.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.CreateAPIKeyRequest true "Key details"
+// @Success 201 {object} response.APIKeyResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse "Owner not found"
+// @Router /api/v1/private/admin/api-keys [post]
+func AdminCreateAPIKeyHandler(keys repository.IAPIKeyRepository, users repository.IUserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		var req schema.CreateAPIKeyRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		ownerID, ownerUUID := claims.UserID, claims.UUID
+		if req.OwnerUUID != "" {
+			owner, err := users.FindByUUID(r.Context(), req.OwnerUUID)
+			if err != nil {
+				response.Error(w, err)
+				return
+			}
+			ownerID, ownerUUID = owner.ID, owner.UUID
+		}
+
+		plaintext, prefix, hash, err := apikey.Generate()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		k := &model.APIKey{
+			Name:      req.Name,
+			Prefix:    prefix,
+			OwnerUUID: ownerUUID,
+			Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+			CreatedAt: time.Now(),
+			Key:       plaintext,
+		}
+		if req.ExpiresInDays > 0 {
+			exp := time.Now().AddDate(0, 0, req.ExpiresInDays)
+			k.ExpiresAt = &exp
+		}
+
+		if err := keys.Create(r.Context(), k, ownerID, hash); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Created(response.SendParams{
+			W:       w,
+			Message: "API key created, store it now as it will not be shown again",
+			Data:    k,
+		})
+	}
+}
+
+// @Summary List API keys
+// @Description Lists every API key, including revoked and expired ones. Plaintext keys are never returned.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.APIKeyListResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/api-keys [get]
+func AdminListAPIKeysHandler(keys repository.IAPIKeyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		list, err := keys.List(r.Context())
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: list,
+		})
+	}
+}
+
+// @Summary Revoke an API key
+// @Description Revokes an API key. Other instances may accept it until their cache expires (API_KEY_CACHE_TTL).
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/api-keys/{id} [delete]
+func AdminRevokeAPIKeyHandler(keys repository.IAPIKeyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			response.Error(w, apperr.ErrNotFound)
+			return
+		}
+
+		k, err := keys.FindByID(r.Context(), id)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := keys.Revoke(r.Context(), id); err != nil {
+			response.Error(w, err)
+			return
+		}
+		apikey.Default().Invalidate(k.Prefix)
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "API key revoked",
+		})
+	}
+}
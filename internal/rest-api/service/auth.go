@@ -1,23 +1,37 @@
 package service
 
 import (
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
 	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/lockout"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
 	"github.com/lakhan-purohit/net-http/internal/pkg/request"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/revocation"
 	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
 )
 
 // @Summary Login
+// @Description Returns the user with a token pair. When 2FA is enabled the result is an
+// @Description MFAChallenge instead; exchange its mfa_token at /auth/2fa/verify.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body schema.LoginRequest true "Login Credentials"
 // @Success 200 {object} response.LoginResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Account pending, inactive or banned"
+// @Failure 429 {object} response.ErrorResponse "Throttled or locked; see Retry-After"
 // @Router /api/v1/public/auth/login [post]
 func LoginHandler(repo repository.IAuthRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -32,34 +46,182 @@ func LoginHandler(repo repository.IAuthRepository) http.HandlerFunc {
 			return
 		}
 
+		event := newLoginEvent(r, req.Email)
+		guard := lockout.Default()
+
+		// 1. Refuse early while the account is locked or the caller is backing off
+		lockedUntil, err := repo.LockedUntil(r.Context(), req.Email)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if lockedUntil != nil {
+			event.FailureReason = apperr.ErrAccountLocked.Code
+			recordLoginEvent(r, repo, event)
+			retryLater(w, apperr.ErrAccountLocked, time.Until(*lockedUntil))
+			return
+		}
+
+		wait, err := guard.Check(r.Context(), req.Email, event.IPAddress)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if wait > 0 {
+			event.FailureReason = apperr.ErrLoginThrottled.Code
+			recordLoginEvent(r, repo, event)
+			retryLater(w, apperr.ErrLoginThrottled, wait)
+			return
+		}
+
+		// 2. Verify credentials
 		user, err := repo.Login(r.Context(), req.Email, req.Password)
 		if err != nil {
-			response.UnauthorizedAccess(response.SendParams{
-				W:       w,
-				Message: err.Error(),
-			})
+			event.FailureReason = failureReason(err)
+			recordLoginEvent(r, repo, event)
+
+			if err == apperr.ErrInvalidCredentials {
+				lock, gerr := guard.Fail(r.Context(), req.Email, event.IPAddress)
+				if gerr != nil {
+					slog.Error("login_guard_failed", "request_id", event.RequestID, "error", gerr)
+				}
+				if lock {
+					if lerr := repo.LockAccount(r.Context(), req.Email, time.Now().Add(guard.LockDuration())); lerr != nil {
+						slog.Error("account_lock_failed", "request_id", event.RequestID, "error", lerr)
+					}
+				}
+			}
+
+			response.Error(w, err)
+			return
+		}
+
+		if err := guard.Succeed(r.Context(), req.Email); err != nil {
+			slog.Error("login_guard_failed", "request_id", event.RequestID, "error", err)
+		}
+
+		if err := checkAccountStatus(user); err != nil {
+			event.FailureReason = failureReason(err)
+			recordLoginEvent(r, repo, event)
+			response.Error(w, err)
+			return
+		}
+
+		// 3. Second factor or tokens
+		completeLogin(w, r, repo, user, event)
+	}
+}
+
+// completeLogin finishes a successful first-factor login. Users with 2FA
+// enabled get an mfa_pending token to exchange at /auth/2fa/verify; everyone
+// else gets a new session straight away.
+func completeLogin(w http.ResponseWriter, r *http.Request, repo repository.IAuthRepository, user *model.User, event *model.LoginEvent) {
+	if user.MFAEnabled {
+		token, err := utils.NewJWT().GenerateMFAPending(utils.Claims{
+			UserID: user.ID,
+			UUID:   user.UUID,
+		})
+		if err != nil {
+			response.Error(w, err)
 			return
 		}
 
 		response.Success(response.SendParams{
-			W:    w,
-			Data: user,
+			W:       w,
+			Message: "Two-factor authentication required",
+			Data: model.MFAChallenge{
+				MFARequired: true,
+				MFAToken:    token,
+			},
 		})
+		return
 	}
+
+	startSession(w, r, repo, user, event)
+}
+
+// startSession issues the token pair, records the login (stats, session and
+// history) and responds with the user.
+func startSession(w http.ResponseWriter, r *http.Request, repo repository.IAuthRepository, user *model.User, event *model.LoginEvent) {
+	pair, session, err := newSession(r, user, "")
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if err := repo.CompleteLogin(r.Context(), user.ID, session); err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	event.Success = true
+	recordLoginEvent(r, repo, event)
+
+	user.Token = pair.AccessToken
+	user.RefreshToken = pair.RefreshToken
+
+	response.Success(response.SendParams{
+		W:    w,
+		Data: user,
+	})
+}
+
+// checkAccountStatus only lets active accounts obtain tokens. A temporary ban
+// or suspension stops applying as soon as it runs out, even before
+// RunStatusExpiry has reset it.
+func checkAccountStatus(user *model.User) error {
+	if user.StatusUntil != nil && !time.Now().Before(*user.StatusUntil) {
+		return nil
+	}
+
+	var err *apperr.AppError
+	switch user.Status {
+	case constants.UserStatusActive:
+		return nil
+	case constants.UserStatusPending:
+		return apperr.ErrAccountPending
+	case constants.UserStatusBanned:
+		err = apperr.ErrAccountBanned
+	case constants.UserStatusSuspended:
+		err = apperr.ErrAccountSuspended
+	default:
+		return apperr.ErrAccountInactive
+	}
+
+	if user.StatusUntil != nil {
+		return err.WithDetails(map[string]time.Time{"until": *user.StatusUntil})
+	}
+	return err
+}
+
+// retryLater responds 429 with a Retry-After header (whole seconds, rounded up)
+func retryLater(w http.ResponseWriter, err *apperr.AppError, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	response.Error(w, err)
+}
+
+// failureReason maps a login error to the short code stored in login_events
+func failureReason(err error) string {
+	if ae, ok := err.(*apperr.AppError); ok {
+		return ae.Code
+	}
+	return apperr.ErrInternal.Code
 }
 
 // @Summary Sign up
+// @Description Creates a pending account and emails a verification code. The account
+// @Description can log in once the email has been verified via /auth/verify-email.
 // @Tags Auth
 // @Accept multipart/form-data
 // @Produce json
 // @Param username formData string true "Desired username" example("johndoe")
 // @Param email formData string true "User email" example("john@example.com")
-// @Param password formData string true "User password" example("password123")
+// @Param password formData string true "User password, checked against the password policy" example("correct-horse-battery")
 // @Param avatar formData file true "Avatar image file"
-// @Success 200 {object} response.LoginResponse
-// @Failure 400 {object} response.ErrorResponse
+// @Success 200 {object} response.UserResponse
+// @Failure 400 {object} response.PasswordPolicyErrorResponse
 // @Router /api/v1/public/auth/sign-up [post]
-func SignUpHandler(repo repository.IAuthRepository) http.HandlerFunc {
+func SignUpHandler(repo repository.IAuthRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
 		var req schema.SignUpRequest
@@ -72,6 +234,11 @@ func SignUpHandler(repo repository.IAuthRepository) http.HandlerFunc {
 			return
 		}
 
+		if err := checkPasswordPolicy(r.Context(), repo, &model.User{Username: req.Username, Email: req.Email}, req.Password); err != nil {
+			response.Error(w, err)
+			return
+		}
+
 		avatar, _ := utils.SaveSingle(req.Avatar, constants.UserAvatarDir)
 
 		user, err := repo.SignUp(req.Username, req.Email, req.Password, avatar.Name)
@@ -83,17 +250,87 @@ func SignUpHandler(repo repository.IAuthRepository) http.HandlerFunc {
 			return
 		}
 
-		jwtService := utils.NewJWT()
+		if err := sendVerificationCode(r.Context(), userTokens, user); err != nil {
+			response.Error(w, err)
+			return
+		}
 
-		token, refresh, _ := jwtService.Generate(utils.Claims{
-			UserID: user.ID,
-			Email:  user.Email,
-			Role:   "user",
-			UUID:   user.UUID,
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Account created, check your email for the verification code",
+			Data:    user,
 		})
+	}
+}
+
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token for a new access/refresh pair. Each refresh token
+// @Description can only be used once; replaying a used token revokes the whole session.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} response.LoginResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/refresh [post]
+func RefreshTokenHandler(repo repository.IAuthRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.RefreshTokenRequest
 
-		user.Token = token
-		user.RefreshToken = refresh
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		// 1. Verify signature & expiry, and make sure it really is a refresh token
+		claims, err := utils.NewJWT().Parse(req.RefreshToken)
+		if err != nil {
+			response.Error(w, apperr.ErrInvalidToken)
+			return
+		}
+		// OAuth refresh tokens are only redeemable at /oauth/token by their client
+		if claims.Type != constants.TokenTypeRefresh || claims.ID == "" || claims.ClientID != "" {
+			response.Error(w, apperr.ErrInvalidTokenType)
+			return
+		}
+
+		// 2. Check persisted state
+		current, err := loadRefreshToken(r.Context(), tokens, claims.ID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		user, err := repo.FindByID(r.Context(), current.UserID)
+		if err != nil {
+			response.Error(w, apperr.ErrInvalidToken)
+			return
+		}
+		if err := checkAccountStatus(user); err != nil {
+			_ = tokens.RevokeFamily(r.Context(), current.FamilyID)
+			response.Error(w, err)
+			return
+		}
+
+		// 3. Rotate
+		pair, next, err := newSession(r, user, current.FamilyID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := rotateRefreshToken(r.Context(), tokens, current, next); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		user.Token = pair.AccessToken
+		user.RefreshToken = pair.RefreshToken
 
 		response.Success(response.SendParams{
 			W:    w,
@@ -101,3 +338,74 @@ func SignUpHandler(repo repository.IAuthRepository) http.HandlerFunc {
 		})
 	}
 }
+
+// @Summary Logout
+// @Description Revokes the presented access token and every refresh and access token of the same session.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/logout [post]
+func LogoutHandler(tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		if claims.ExpiresAt != nil {
+			if err := revocation.Default().Revoke(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+				response.Error(w, err)
+				return
+			}
+		}
+
+		if claims.SessionID != "" {
+			if err := tokens.RevokeFamily(r.Context(), claims.SessionID); err != nil {
+				response.Error(w, err)
+				return
+			}
+			if err := revokeSessionAccess(r.Context(), claims.SessionID); err != nil {
+				response.Error(w, err)
+				return
+			}
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Logged out",
+		})
+	}
+}
+
+// @Summary Logout from all devices
+// @Description Revokes every access and refresh token issued to the current user.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/logout-all [post]
+func LogoutAllHandler(tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		if err := revokeAllSessions(r.Context(), tokens, claims.UserID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Logged out from all devices",
+		})
+	}
+}
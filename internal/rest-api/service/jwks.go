@@ -0,0 +1,31 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+)
+
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying tokens issued by this API (RFC 7517). Empty while only HS256 is used.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} utils.JWKSet
+// @Router /.well-known/jwks.json [get]
+func JWKSHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		keys, err := utils.LoadJWTKeys()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// Standard JWKS document, not wrapped in the usual response envelope
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_ = json.NewEncoder(w).Encode(keys.JWKS())
+	}
+}
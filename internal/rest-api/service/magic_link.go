@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/mailer"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Request a magic link
+// @Description Emails a single-use sign-in link. The response is the same whether or not the account exists, and requests over the per-address limit are silently dropped.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.MagicLinkRequest true "Account email"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/magic-link [post]
+func MagicLinkHandler(repo repository.IAuthRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.MagicLinkRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		const sent = "If the account exists, a sign-in link has been sent"
+
+		user, err := repo.FindByEmail(r.Context(), req.Email)
+		if err == apperr.ErrNotFound {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// Accounts that could not sign in anyway get nothing
+		if checkAccountStatus(user) != nil {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+
+		cfg := config.Get().Auth
+		limited, err := sendLimited(r.Context(), userTokens, user.ID, constants.TokenPurposeMagicLink, cfg.MagicLinkRateLimit, cfg.MagicLinkRateWindow)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if limited {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+
+		token, err := randomToken()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := userTokens.Create(r.Context(), &model.UserToken{
+			UserID:    user.ID,
+			Purpose:   constants.TokenPurposeMagicLink,
+			TokenHash: utils.HashToken(token),
+			Target:    user.Email,
+			ExpiresAt: time.Now().Add(cfg.MagicLinkTTL),
+		}); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		link, err := url.Parse(cfg.MagicLinkURL)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		q := link.Query()
+		q.Set("email", user.Email)
+		q.Set("token", token)
+		link.RawQuery = q.Encode()
+
+		deliver(mailer.Message{
+			To:      user.Email,
+			Subject: "Your sign-in link",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nUse this link to sign in: %s\n\nIt works once and expires in %s. If you did not ask to sign in you can ignore this email.\n",
+				user.Username, link.String(), cfg.MagicLinkTTL,
+			),
+		})
+
+		response.Success(response.SendParams{W: w, Message: sent})
+	}
+}
+
+// @Summary Sign in with a magic link
+// @Description Exchanges the token from an emailed link, together with the email it was sent to, for a token pair. Local 2FA still applies.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.MagicLinkConsumeRequest true "Email and link token"
+// @Success 200 {object} response.LoginResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/magic-link/consume [post]
+func MagicLinkConsumeHandler(repo repository.IAuthRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.MagicLinkConsumeRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		event := newLoginEvent(r, req.Email)
+
+		user, err := repo.FindByEmail(r.Context(), req.Email)
+		if err == apperr.ErrNotFound {
+			event.FailureReason = apperr.ErrInvalidCode.Code
+			recordLoginEvent(r, repo, event)
+			response.Error(w, apperr.ErrInvalidCode)
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		t, err := verifyOTP(r.Context(), userTokens, user.ID, constants.TokenPurposeMagicLink, req.Token)
+		if err == nil && t.Target != user.Email {
+			// The address changed since the link was sent
+			err = apperr.ErrInvalidCode
+		}
+		if err == nil {
+			if err = userTokens.Consume(r.Context(), t.ID); err == apperr.ErrInvalidToken {
+				err = apperr.ErrInvalidCode
+			}
+		}
+		if err != nil {
+			event.FailureReason = failureReason(err)
+			recordLoginEvent(r, repo, event)
+			response.Error(w, err)
+			return
+		}
+
+		if err := checkAccountStatus(user); err != nil {
+			event.FailureReason = failureReason(err)
+			recordLoginEvent(r, repo, event)
+			response.Error(w, err)
+			return
+		}
+
+		completeLogin(w, r, repo, user, event)
+	}
+}
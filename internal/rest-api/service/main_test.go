@@ -0,0 +1,15 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+)
+
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Setenv("JWT_ACCESS_EXPIRES_IN", "15m")
+	config.Load()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/lockout"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/revocation"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789" // no 0/o, 1/l/i
+)
+
+// @Summary Start 2FA setup
+// @Description Generates a TOTP secret for the current user. 2FA is only enabled once a code is confirmed.
+// @Tags User
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.MFASetupResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Failure 409 {object} response.ErrorResponse "2FA already enabled"
+// @Router /api/v1/private/user/2fa/setup [post]
+func MFASetupHandler(mfa repository.IMFARepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		state, err := mfa.GetTOTP(r.Context(), claims.UserID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if state.Enabled {
+			response.Error(w, apperr.ErrMFAAlreadyEnabled)
+			return
+		}
+
+		secret, err := utils.GenerateTOTPSecret()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := mfa.SetPendingSecret(r.Context(), claims.UserID, secret); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W: w,
+			Data: model.MFASetup{
+				Secret:     secret,
+				OTPAuthURI: utils.TOTPURI(config.Get().Auth.MFAIssuer, claims.Email, secret),
+			},
+		})
+	}
+}
+
+// @Summary Confirm 2FA setup
+// @Description Enables 2FA with a code from the authenticator app and returns recovery codes. The codes are shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.MFAConfirmRequest true "Current TOTP code"
+// @Success 200 {object} response.MFARecoveryCodesResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Failure 409 {object} response.ErrorResponse "2FA already enabled"
+// @Router /api/v1/private/user/2fa/confirm [post]
+func MFAConfirmHandler(mfa repository.IMFARepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		var req schema.MFAConfirmRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		state, err := mfa.GetTOTP(r.Context(), claims.UserID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if state.Enabled {
+			response.Error(w, apperr.ErrMFAAlreadyEnabled)
+			return
+		}
+		if state.Secret == "" {
+			response.Error(w, apperr.ErrMFANotSetUp)
+			return
+		}
+
+		step, ok := utils.VerifyTOTP(state.Secret, req.Code, time.Now(), 1)
+		if !ok {
+			response.Error(w, apperr.ErrInvalidMFACode)
+			return
+		}
+
+		codes := make([]string, recoveryCodeCount)
+		hashes := make([]string, recoveryCodeCount)
+		for i := range codes {
+			raw, err := utils.RandomString(10, recoveryCodeAlphabet)
+			if err != nil {
+				response.Error(w, err)
+				return
+			}
+			codes[i] = raw[:5] + "-" + raw[5:]
+
+			if hashes[i], err = utils.HashPassword(codes[i]); err != nil {
+				response.Error(w, err)
+				return
+			}
+		}
+
+		if err := mfa.Enable(r.Context(), claims.UserID, step, hashes); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Two-factor authentication enabled",
+			Data:    model.MFARecoveryCodes{RecoveryCodes: codes},
+		})
+	}
+}
+
+// @Summary Verify 2FA code
+// @Description Exchanges the mfa_token returned by login and a TOTP or recovery code for a token pair.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.MFAVerifyRequest true "MFA token and code"
+// @Success 200 {object} response.LoginResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 429 {object} response.ErrorResponse "Throttled; see Retry-After"
+// @Router /api/v1/public/auth/2fa/verify [post]
+func MFAVerifyHandler(repo repository.IAuthRepository, mfa repository.IMFARepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.MFAVerifyRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		claims, err := utils.NewJWT().Parse(req.MFAToken)
+		if err != nil {
+			response.Error(w, apperr.ErrInvalidToken)
+			return
+		}
+		if claims.Type != constants.TokenTypeMFAPending || claims.ID == "" {
+			response.Error(w, apperr.ErrInvalidTokenType)
+			return
+		}
+
+		// The pending token is single-use
+		revoked, err := revocation.Default().IsRevoked(r.Context(), claims.ID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if revoked {
+			response.Error(w, apperr.ErrTokenRevoked)
+			return
+		}
+
+		user, err := repo.FindByID(r.Context(), claims.UserID)
+		if err == apperr.ErrNotFound {
+			response.Error(w, apperr.ErrInvalidToken)
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		event := newLoginEvent(r, user.Email)
+		guard := lockout.Default()
+
+		if err := checkAccountStatus(user); err != nil {
+			event.FailureReason = failureReason(err)
+			recordLoginEvent(r, repo, event)
+			response.Error(w, err)
+			return
+		}
+
+		// Wrong codes count against the same budget as wrong passwords
+		wait, err := guard.Check(r.Context(), user.Email, event.IPAddress)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if wait > 0 {
+			event.FailureReason = apperr.ErrLoginThrottled.Code
+			recordLoginEvent(r, repo, event)
+			retryLater(w, apperr.ErrLoginThrottled, wait)
+			return
+		}
+
+		ok, err := verifySecondFactor(r.Context(), mfa, user.ID, req.Code)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if !ok {
+			event.FailureReason = apperr.ErrInvalidMFACode.Code
+			recordLoginEvent(r, repo, event)
+
+			if _, gerr := guard.Fail(r.Context(), user.Email, event.IPAddress); gerr != nil {
+				slog.Error("login_guard_failed", "request_id", event.RequestID, "error", gerr)
+			}
+
+			response.Error(w, apperr.ErrInvalidMFACode)
+			return
+		}
+
+		if err := revocation.Default().Revoke(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := guard.Succeed(r.Context(), user.Email); err != nil {
+			slog.Error("login_guard_failed", "request_id", event.RequestID, "error", err)
+		}
+
+		startSession(w, r, repo, user, event)
+	}
+}
+
+// verifySecondFactor accepts either a current TOTP code (each time step only
+// once) or one of the user's unused recovery codes.
+func verifySecondFactor(ctx context.Context, mfa repository.IMFARepository, userID int64, code string) (bool, error) {
+	state, err := mfa.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !state.Enabled {
+		return false, nil
+	}
+
+	if step, ok := utils.VerifyTOTP(state.Secret, code, time.Now(), 1); ok {
+		return mfa.MarkStepUsed(ctx, userID, step)
+	}
+
+	codes, err := mfa.ListRecoveryCodes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range codes {
+		if utils.ComparePassword(c.CodeHash, code) {
+			return mfa.UseRecoveryCode(ctx, c.ID)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeMFARepo mirrors the conditional updates of MFARepository in memory
+type fakeMFARepo struct {
+	repository.IMFARepository
+	state *model.TOTPState
+	codes []*model.RecoveryCode
+	used  map[int64]bool
+}
+
+func (r *fakeMFARepo) GetTOTP(context.Context, int64) (*model.TOTPState, error) {
+	return r.state, nil
+}
+
+func (r *fakeMFARepo) MarkStepUsed(_ context.Context, _, step int64) (bool, error) {
+	if r.state.LastStep != nil && *r.state.LastStep >= step {
+		return false, nil
+	}
+	r.state.LastStep = &step
+	return true, nil
+}
+
+func (r *fakeMFARepo) ListRecoveryCodes(context.Context, int64) ([]*model.RecoveryCode, error) {
+	var unused []*model.RecoveryCode
+	for _, c := range r.codes {
+		if !r.used[c.ID] {
+			unused = append(unused, c)
+		}
+	}
+	return unused, nil
+}
+
+func (r *fakeMFARepo) UseRecoveryCode(_ context.Context, id int64) (bool, error) {
+	if r.used[id] {
+		return false, nil
+	}
+	r.used[id] = true
+	return true, nil
+}
+
+// currentTOTP computes the RFC 6238 code for now, independently of utils
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	o := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[o:]) & 0x7fffffff
+	return fmt.Sprintf("%06d", bin%1000000)
+}
+
+func TestVerifySecondFactor(t *testing.T) {
+	utils.SetPasswordHasher(utils.BcryptHasher{Cost: bcrypt.MinCost})
+	t.Cleanup(func() { utils.SetPasswordHasher(nil) })
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recoveryHash, err := utils.HashPassword("k7f2m-9xq4p")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRepo := func(enabled bool) *fakeMFARepo {
+		return &fakeMFARepo{
+			state: &model.TOTPState{Secret: secret, Enabled: enabled},
+			codes: []*model.RecoveryCode{{ID: 1, CodeHash: recoveryHash}},
+			used:  map[int64]bool{},
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("totp code works once", func(t *testing.T) {
+		mfa := newRepo(true)
+		code := currentTOTP(t, secret)
+
+		if ok, err := verifySecondFactor(ctx, mfa, 1, code); !ok || err != nil {
+			t.Fatalf("first use = (%v, %v), want accepted", ok, err)
+		}
+		if ok, err := verifySecondFactor(ctx, mfa, 1, code); ok || err != nil {
+			t.Fatalf("replay = (%v, %v), want refused", ok, err)
+		}
+	})
+
+	t.Run("older step after a newer one", func(t *testing.T) {
+		mfa := newRepo(true)
+		future := time.Now().Unix()/30 + 1
+		mfa.state.LastStep = &future
+
+		if ok, _ := verifySecondFactor(ctx, mfa, 1, currentTOTP(t, secret)); ok {
+			t.Fatal("code for an already passed step accepted")
+		}
+	})
+
+	t.Run("recovery code works once", func(t *testing.T) {
+		mfa := newRepo(true)
+
+		if ok, err := verifySecondFactor(ctx, mfa, 1, "k7f2m-9xq4p"); !ok || err != nil {
+			t.Fatalf("first use = (%v, %v), want accepted", ok, err)
+		}
+		if ok, _ := verifySecondFactor(ctx, mfa, 1, "k7f2m-9xq4p"); ok {
+			t.Fatal("recovery code accepted twice")
+		}
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		if ok, _ := verifySecondFactor(ctx, newRepo(true), 1, "000000x"); ok {
+			t.Fatal("wrong code accepted")
+		}
+	})
+
+	t.Run("2fa not enabled", func(t *testing.T) {
+		if ok, _ := verifySecondFactor(ctx, newRepo(false), 1, currentTOTP(t, secret)); ok {
+			t.Fatal("code accepted for a user without 2FA")
+		}
+	})
+}
@@ -0,0 +1,686 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/revocation"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// RFC 6749 section 5.2 error codes
+const (
+	oauthInvalidRequest       = "invalid_request"
+	oauthInvalidClient        = "invalid_client"
+	oauthInvalidGrant         = "invalid_grant"
+	oauthInvalidScope         = "invalid_scope"
+	oauthUnauthorizedClient   = "unauthorized_client"
+	oauthUnsupportedGrantType = "unsupported_grant_type"
+	oauthUnsupportedTokenType = "unsupported_token_type"
+	oauthAccessDenied         = "access_denied"
+)
+
+// @Summary Describe an authorization request
+// @Description Validates an OAuth2 authorization request for the logged-in user and returns what the client is asking for, so a consent screen can be shown.
+// @Tags OAuth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space separated scopes"
+// @Param state query string false "Opaque client state"
+// @Param code_challenge query string true "PKCE challenge"
+// @Param code_challenge_method query string true "Must be S256"
+// @Success 200 {object} response.OAuthConsentResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Router /oauth/authorize [get]
+func OAuthConsentHandler(oauth repository.IOAuthRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		var req schema.AuthorizeRequest
+
+		err := request.BindQuery(r, &req)
+		if err == nil {
+			err = request.ValidateStruct(&req)
+		}
+		if err != nil {
+			response.BadRequest(response.SendParams{W: w, Message: err.Error()})
+			return
+		}
+
+		client, scope, ok := checkAuthorizeRequest(w, r, oauth, &req)
+		if !ok {
+			return
+		}
+
+		response.Success(response.SendParams{
+			W: w,
+			Data: model.OAuthConsent{
+				ClientID:   client.ClientID,
+				ClientName: client.Name,
+				Scopes:     strings.Fields(scope),
+			},
+		})
+	}
+}
+
+// @Summary Approve or deny an authorization request
+// @Description Records the logged-in user's decision. On approval a single-use authorization code bound to the PKCE challenge is issued; either way the user agent should be sent to redirect_to.
+// @Tags OAuth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.AuthorizeDecisionRequest true "Authorization request and decision"
+// @Success 200 {object} response.OAuthRedirectResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonated request"
+// @Router /oauth/authorize [post]
+func OAuthAuthorizeHandler(oauth repository.IOAuthRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+		if claims.Actor != nil {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		var req schema.AuthorizeDecisionRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{W: w, Message: err.Error()})
+			return
+		}
+
+		client, scope, ok := checkAuthorizeRequest(w, r, oauth, &req.AuthorizeRequest)
+		if !ok {
+			return
+		}
+
+		params := url.Values{}
+		if req.State != "" {
+			params.Set("state", req.State)
+		}
+
+		if !req.Approve {
+			params.Set("error", oauthAccessDenied)
+			respondRedirect(w, req.RedirectURI, params)
+			return
+		}
+
+		code, err := randomToken()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := oauth.CreateCode(r.Context(), utils.HashToken(code), &model.AuthorizationCode{
+			ClientID:      client.ClientID,
+			UserID:        claims.UserID,
+			RedirectURI:   req.RedirectURI,
+			Scope:         scope,
+			CodeChallenge: req.CodeChallenge,
+			ExpiresAt:     time.Now().Add(config.Get().Auth.OAuthCodeTTL),
+		}); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		params.Set("code", code)
+		respondRedirect(w, req.RedirectURI, params)
+	}
+}
+
+// checkAuthorizeRequest validates the client, redirect URI and scope of an
+// authorization request and returns the scope to grant. Problems are reported
+// to the caller instead of the redirect URI because it is our own consent UI.
+func checkAuthorizeRequest(w http.ResponseWriter, r *http.Request, oauth repository.IOAuthRepository, req *schema.AuthorizeRequest) (*model.OAuthClient, string, bool) {
+	client, err := oauth.FindClient(r.Context(), req.ClientID)
+	if err == apperr.ErrNotFound {
+		response.BadRequest(response.SendParams{W: w, Message: "Unknown client"})
+		return nil, "", false
+	}
+	if err != nil {
+		response.Error(w, err)
+		return nil, "", false
+	}
+
+	if !client.AllowsGrant(constants.GrantAuthorizationCode) {
+		response.BadRequest(response.SendParams{W: w, Message: "Client may not use the authorization code grant"})
+		return nil, "", false
+	}
+	if !client.AllowsRedirect(req.RedirectURI) {
+		response.BadRequest(response.SendParams{W: w, Message: "redirect_uri is not registered for this client"})
+		return nil, "", false
+	}
+
+	scope, ok := grantScope(req.Scope, client.Scopes)
+	if !ok {
+		response.BadRequest(response.SendParams{W: w, Message: "Requested scope is not allowed for this client"})
+		return nil, "", false
+	}
+
+	return client, scope, true
+}
+
+// @Summary OAuth2 token endpoint
+// @Description RFC 6749 token endpoint supporting authorization_code (with PKCE), refresh_token and client_credentials. Clients authenticate with HTTP Basic or client_id/client_secret form fields; public clients send client_id only.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Param code formData string false "Authorization code"
+// @Param redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param code_verifier formData string false "PKCE verifier"
+// @Param refresh_token formData string false "Refresh token"
+// @Param scope formData string false "Requested scope (subset of what was granted)"
+// @Param client_id formData string false "Client ID (when not using HTTP Basic)"
+// @Param client_secret formData string false "Client secret (when not using HTTP Basic)"
+// @Success 200 {object} model.OAuthToken
+// @Failure 400 {object} model.OAuthError
+// @Failure 401 {object} model.OAuthError
+// @Router /oauth/token [post]
+func OAuthTokenHandler(oauth repository.IOAuthRepository, repo repository.IAuthRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client, ok := authenticateClient(w, r, oauth)
+		if !ok {
+			return
+		}
+
+		grant := r.PostForm.Get("grant_type")
+		if grant != constants.GrantAuthorizationCode && grant != constants.GrantRefreshToken && grant != constants.GrantClientCredentials {
+			oauthError(w, http.StatusBadRequest, oauthUnsupportedGrantType, "")
+			return
+		}
+		if !client.AllowsGrant(grant) {
+			oauthError(w, http.StatusBadRequest, oauthUnauthorizedClient, "grant type not allowed for this client")
+			return
+		}
+
+		switch grant {
+		case constants.GrantAuthorizationCode:
+			exchangeAuthorizationCode(w, r, client, oauth, repo, tokens)
+		case constants.GrantRefreshToken:
+			exchangeRefreshToken(w, r, client, repo, tokens)
+		case constants.GrantClientCredentials:
+			issueClientCredentials(w, r, client)
+		}
+	}
+}
+
+func exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, client *model.OAuthClient, oauth repository.IOAuthRepository, repo repository.IAuthRepository, tokens repository.ITokenRepository) {
+	code := r.PostForm.Get("code")
+	verifier := r.PostForm.Get("code_verifier")
+	if code == "" || verifier == "" {
+		oauthError(w, http.StatusBadRequest, oauthInvalidRequest, "code and code_verifier are required")
+		return
+	}
+
+	grant, err := oauth.ConsumeCode(r.Context(), utils.HashToken(code))
+	if err == apperr.ErrInvalidCode {
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "invalid or expired code")
+		return
+	}
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if grant.ClientID != client.ClientID ||
+		grant.RedirectURI != r.PostForm.Get("redirect_uri") ||
+		!verifyPKCE(grant.CodeChallenge, verifier) {
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "code was not issued for this request")
+		return
+	}
+
+	user, err := repo.FindByID(r.Context(), grant.UserID)
+	if err != nil || checkAccountStatus(user) != nil {
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "user is not allowed to sign in")
+		return
+	}
+
+	pair, session, err := newDelegatedSession(r, user, client.ClientID, grant.Scope, "")
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	res := model.OAuthToken{
+		AccessToken: pair.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(pair.AccessExpiresAt).Seconds()),
+		Scope:       grant.Scope,
+	}
+
+	// A refresh token needs both the offline_access scope and the grant
+	if slices.Contains(strings.Fields(grant.Scope), constants.ScopeOfflineAccess) &&
+		client.AllowsGrant(constants.GrantRefreshToken) {
+		if err := tokens.Create(r.Context(), session); err != nil {
+			response.Error(w, err)
+			return
+		}
+		res.RefreshToken = pair.RefreshToken
+	}
+
+	oauthJSON(w, http.StatusOK, res)
+}
+
+func exchangeRefreshToken(w http.ResponseWriter, r *http.Request, client *model.OAuthClient, repo repository.IAuthRepository, tokens repository.ITokenRepository) {
+	claims, err := utils.NewJWT().Parse(r.PostForm.Get("refresh_token"))
+	if err != nil || claims.Type != constants.TokenTypeRefresh || claims.ID == "" || claims.ClientID != client.ClientID {
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "invalid refresh token")
+		return
+	}
+
+	current, err := loadRefreshToken(r.Context(), tokens, claims.ID)
+	if err != nil {
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "invalid refresh token")
+		return
+	}
+
+	// The client may narrow, never widen, the original grant
+	scope := current.Scope
+	if requested := r.PostForm.Get("scope"); requested != "" {
+		var ok bool
+		if scope, ok = grantScope(requested, strings.Fields(current.Scope)); !ok {
+			oauthError(w, http.StatusBadRequest, oauthInvalidScope, "")
+			return
+		}
+	}
+
+	user, err := repo.FindByID(r.Context(), current.UserID)
+	if err != nil || checkAccountStatus(user) != nil {
+		_ = tokens.RevokeFamily(r.Context(), current.FamilyID)
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "user is not allowed to sign in")
+		return
+	}
+
+	pair, next, err := newDelegatedSession(r, user, client.ClientID, scope, current.FamilyID)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if err := rotateRefreshToken(r.Context(), tokens, current, next); err != nil {
+		if err == apperr.ErrTokenReused {
+			oauthError(w, http.StatusBadRequest, oauthInvalidGrant, "invalid refresh token")
+			return
+		}
+		response.Error(w, err)
+		return
+	}
+
+	oauthJSON(w, http.StatusOK, model.OAuthToken{
+		AccessToken:  pair.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(pair.AccessExpiresAt).Seconds()),
+		RefreshToken: pair.RefreshToken,
+		Scope:        scope,
+	})
+}
+
+func issueClientCredentials(w http.ResponseWriter, r *http.Request, client *model.OAuthClient) {
+	if !client.Confidential {
+		oauthError(w, http.StatusBadRequest, oauthUnauthorizedClient, "public clients cannot use client_credentials")
+		return
+	}
+
+	// There is no user to come back for, so offline_access makes no sense here
+	allowed := slices.DeleteFunc(slices.Clone(client.Scopes), func(s string) bool {
+		return s == constants.ScopeOfflineAccess
+	})
+	scope, ok := grantScope(r.PostForm.Get("scope"), allowed)
+	if !ok {
+		oauthError(w, http.StatusBadRequest, oauthInvalidScope, "")
+		return
+	}
+
+	token, expiresAt, err := utils.NewJWT().GenerateClientAccess(client.ClientID, scope)
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	oauthJSON(w, http.StatusOK, model.OAuthToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(time.Until(expiresAt).Seconds()),
+		Scope:       scope,
+	})
+}
+
+// @Summary Token introspection
+// @Description RFC 7662 introspection for confidential clients. Inactive, unknown or malformed tokens all yield {"active": false}.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} model.Introspection
+// @Failure 401 {object} model.OAuthError
+// @Router /oauth/introspect [post]
+func OAuthIntrospectHandler(oauth repository.IOAuthRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client, ok := authenticateClient(w, r, oauth)
+		if !ok {
+			return
+		}
+		if !client.Confidential {
+			oauthError(w, http.StatusUnauthorized, oauthInvalidClient, "introspection requires a confidential client")
+			return
+		}
+
+		inactive := model.Introspection{Active: false}
+
+		claims, err := utils.NewJWT().Parse(r.PostForm.Get("token"))
+		if err != nil {
+			oauthJSON(w, http.StatusOK, inactive)
+			return
+		}
+
+		var tokenType string
+		switch claims.Type {
+		case constants.TokenTypeAccess:
+			revoked, err := middleware.IsTokenRevoked(r.Context(), claims)
+			if err != nil {
+				response.Error(w, err)
+				return
+			}
+			if revoked {
+				oauthJSON(w, http.StatusOK, inactive)
+				return
+			}
+			tokenType = "access_token"
+		case constants.TokenTypeRefresh:
+			t, err := tokens.FindByTokenID(r.Context(), claims.ID)
+			if err != nil || t.UsedAt != nil || t.RevokedAt != nil {
+				oauthJSON(w, http.StatusOK, inactive)
+				return
+			}
+			tokenType = "refresh_token"
+		default:
+			oauthJSON(w, http.StatusOK, inactive)
+			return
+		}
+
+		res := model.Introspection{
+			Active:    true,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			TokenType: tokenType,
+			Sub:       claims.Subject,
+			Aud:       claims.Audience,
+			Iss:       claims.Issuer,
+			Jti:       claims.ID,
+		}
+		if claims.ExpiresAt != nil {
+			res.Exp = claims.ExpiresAt.Unix()
+		}
+		if claims.IssuedAt != nil {
+			res.Iat = claims.IssuedAt.Unix()
+		}
+		if claims.NotBefore != nil {
+			res.Nbf = claims.NotBefore.Unix()
+		}
+
+		oauthJSON(w, http.StatusOK, res)
+	}
+}
+
+// @Summary Token revocation
+// @Description RFC 7009 revocation. Revoking a refresh token ends the whole session; revoking an access token blocks it until it expires. Unknown tokens are ignored.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200
+// @Failure 400 {object} model.OAuthError
+// @Failure 401 {object} model.OAuthError
+// @Router /oauth/revoke [post]
+func OAuthRevokeHandler(oauth repository.IOAuthRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		client, ok := authenticateClient(w, r, oauth)
+		if !ok {
+			return
+		}
+
+		claims, err := utils.NewJWT().Parse(r.PostForm.Get("token"))
+		if err != nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if claims.ClientID != client.ClientID {
+			oauthError(w, http.StatusBadRequest, oauthUnauthorizedClient, "token was issued to another client")
+			return
+		}
+
+		switch claims.Type {
+		case constants.TokenTypeRefresh:
+			t, err := tokens.FindByTokenID(r.Context(), claims.ID)
+			if err == nil {
+				err = tokens.RevokeFamily(r.Context(), t.FamilyID)
+			}
+			if err != nil && err != apperr.ErrInvalidToken {
+				response.Error(w, err)
+				return
+			}
+		case constants.TokenTypeAccess:
+			if claims.ExpiresAt != nil {
+				if err := revocation.Default().Revoke(r.Context(), claims.ID, claims.ExpiresAt.Time); err != nil {
+					response.Error(w, err)
+					return
+				}
+			}
+		default:
+			oauthError(w, http.StatusBadRequest, oauthUnsupportedTokenType, "")
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// @Summary OAuth2 user info
+// @Description Returns the user a token was issued for. Delegated tokens need the profile scope; the email is only included with the email scope.
+// @Tags OAuth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} model.UserInfo
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /oauth/userinfo [get]
+func OAuthUserInfoHandler(repo repository.IAuthRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		user, err := repo.FindByID(r.Context(), claims.UserID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		info := model.UserInfo{
+			Sub:      user.UUID,
+			Username: user.Username,
+			Avatar:   user.Avatar,
+		}
+		if claims.ClientID == "" || slices.Contains(strings.Fields(claims.Scope), constants.ScopeEmail) {
+			info.Email = user.Email
+		}
+
+		oauthJSON(w, http.StatusOK, info)
+	}
+}
+
+// authenticateClient identifies the client of a token endpoint request from
+// HTTP Basic credentials or the client_id/client_secret form fields.
+// Confidential clients must present their secret; public clients must not
+// have one. On failure the RFC 6749 error has already been written.
+func authenticateClient(w http.ResponseWriter, r *http.Request, oauth repository.IOAuthRepository) (*model.OAuthClient, bool) {
+	if err := r.ParseForm(); err != nil {
+		oauthError(w, http.StatusBadRequest, oauthInvalidRequest, "malformed form body")
+		return nil, false
+	}
+
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		// RFC 6749 section 2.3.1: credentials are form-encoded before Basic encoding
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	fail := func() (*model.OAuthClient, bool) {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		oauthError(w, http.StatusUnauthorized, oauthInvalidClient, "")
+		return nil, false
+	}
+
+	if clientID == "" {
+		return fail()
+	}
+
+	client, err := oauth.FindClient(r.Context(), clientID)
+	if err == apperr.ErrNotFound {
+		return fail()
+	}
+	if err != nil {
+		response.Error(w, err)
+		return nil, false
+	}
+
+	if client.Confidential {
+		if secret == "" || !utils.CompareSecretHash(*client.SecretHash, secret) {
+			return fail()
+		}
+	} else if secret != "" {
+		return fail()
+	}
+
+	return client, true
+}
+
+// grantScope checks a space separated scope request against the allowed
+// scopes. An empty request grants everything allowed.
+func grantScope(requested string, allowed []string) (string, bool) {
+	if requested == "" {
+		return strings.Join(allowed, " "), true
+	}
+
+	var granted []string
+	for _, s := range strings.Fields(requested) {
+		if !slices.Contains(allowed, s) {
+			return "", false
+		}
+		if !slices.Contains(granted, s) {
+			granted = append(granted, s)
+		}
+	}
+	return strings.Join(granted, " "), true
+}
+
+// verifyPKCE checks an S256 code verifier (RFC 7636) against its challenge
+func verifyPKCE(challenge, verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(pkceChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// pkceChallenge derives the S256 challenge of a code verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomToken returns 256 random bits, hex encoded
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func respondRedirect(w http.ResponseWriter, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		response.Error(w, apperr.ErrBadRequest)
+		return
+	}
+
+	q := u.Query()
+	for k, vs := range params {
+		for _, v := range vs {
+			q.Add(k, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	response.Success(response.SendParams{
+		W:    w,
+		Data: model.OAuthRedirect{RedirectTo: u.String()},
+	})
+}
+
+// oauthJSON writes a bare (unenveloped) JSON body as the OAuth RFCs require.
+// Token responses must never be cached.
+func oauthJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func oauthError(w http.ResponseWriter, status int, code, description string) {
+	oauthJSON(w, status, model.OAuthError{Error: code, Description: description})
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Register an OAuth2 client
+// @Description Registers a partner application. Confidential clients receive a client_secret, which is only returned here.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body schema.CreateOAuthClientRequest true "Client details"
+// @Success 201 {object} response.OAuthClientResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/oauth/clients [post]
+func AdminCreateOAuthClientHandler(oauth repository.IOAuthRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.CreateOAuthClientRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		if msg := checkClientGrants(&req); msg != "" {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: msg,
+			})
+			return
+		}
+
+		suffix, err := utils.RandomString(12, "0123456789abcdef")
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		c := &model.OAuthClient{
+			ClientID:     "c_" + suffix,
+			Name:         req.Name,
+			RedirectURIs: req.RedirectURIs,
+			Scopes:       req.Scopes,
+			GrantTypes:   req.GrantTypes,
+			Confidential: req.Confidential,
+			CreatedAt:    time.Now(),
+		}
+		if c.RedirectURIs == nil {
+			c.RedirectURIs = []string{}
+		}
+
+		var secretHash string
+		if req.Confidential {
+			if c.ClientSecret, err = randomToken(); err != nil {
+				response.Error(w, err)
+				return
+			}
+			secretHash = utils.HashSecret(c.ClientSecret)
+		}
+
+		if err := oauth.CreateClient(r.Context(), c, secretHash); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		message := "OAuth client registered"
+		if req.Confidential {
+			message = "OAuth client registered, store the secret now as it will not be shown again"
+		}
+
+		response.Created(response.SendParams{
+			W:       w,
+			Message: message,
+			Data:    c,
+		})
+	}
+}
+
+// checkClientGrants rejects grant combinations that could never be used
+func checkClientGrants(req *schema.CreateOAuthClientRequest) string {
+	has := func(g string) bool { return slices.Contains(req.GrantTypes, g) }
+
+	if has(constants.GrantAuthorizationCode) && len(req.RedirectURIs) == 0 {
+		return "authorization_code clients need at least one redirect URI"
+	}
+	if has(constants.GrantClientCredentials) && !req.Confidential {
+		return "client_credentials is only available to confidential clients"
+	}
+	if has(constants.GrantRefreshToken) && !has(constants.GrantAuthorizationCode) {
+		return "refresh_token requires the authorization_code grant"
+	}
+	return ""
+}
+
+// @Summary List OAuth2 clients
+// @Description Lists every registered client, including revoked ones. Secrets are never returned.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OAuthClientListResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/oauth/clients [get]
+func AdminListOAuthClientsHandler(oauth repository.IOAuthRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		list, err := oauth.ListClients(r.Context())
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: list,
+		})
+	}
+}
+
+// @Summary Revoke an OAuth2 client
+// @Description Disables the client and revokes every refresh token issued to it. Access tokens it already holds stay valid until they expire.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param client_id path string true "Client ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/oauth/clients/{client_id} [delete]
+func AdminRevokeOAuthClientHandler(oauth repository.IOAuthRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if err := oauth.RevokeClient(r.Context(), r.PathValue("client_id")); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "OAuth client revoked",
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// RFC 7636 Appendix B
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	if got := pkceChallenge(verifier); got != challenge {
+		t.Fatalf("pkceChallenge = %q, want %q", got, challenge)
+	}
+
+	long := strings.Repeat("a", 128)
+
+	tests := []struct {
+		name      string
+		challenge string
+		verifier  string
+		want      bool
+	}{
+		{name: "rfc 7636 example", challenge: challenge, verifier: verifier, want: true},
+		{name: "longest verifier", challenge: pkceChallenge(long), verifier: long, want: true},
+		{name: "wrong verifier", challenge: challenge, verifier: "eBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"},
+		{name: "plain method", challenge: verifier, verifier: verifier},
+		{name: "padded challenge", challenge: challenge + "=", verifier: verifier},
+		{name: "empty challenge", challenge: "", verifier: verifier},
+		{name: "empty verifier", challenge: pkceChallenge(""), verifier: ""},
+		{name: "verifier too short", challenge: pkceChallenge(verifier[:42]), verifier: verifier[:42]},
+		{name: "verifier too long", challenge: pkceChallenge(long + "a"), verifier: long + "a"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := verifyPKCE(tc.challenge, tc.verifier); got != tc.want {
+				t.Errorf("verifyPKCE = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/oidc"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Start external sign-in
+// @Description Starts an OpenID Connect login with a configured identity provider. Send the user agent to authorization_url and keep state; the provider redirects back to the registered callback page with code and state.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Provider name from OIDC_PROVIDERS"
+// @Success 200 {object} response.OIDCStartResponse
+// @Failure 404 {object} response.ErrorResponse "Unknown provider"
+// @Failure 502 {object} response.ErrorResponse "Provider unavailable"
+// @Router /api/v1/public/auth/oidc/{provider}/start [post]
+func OIDCStartHandler(identities repository.IIdentityRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		provider, ok := oidc.Default().Get(r.PathValue("provider"))
+		if !ok {
+			response.Error(w, apperr.ErrUnknownProvider)
+			return
+		}
+
+		state, err := randomToken()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		nonce, err := randomToken()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		verifier, err := randomToken()
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		authURL, err := provider.AuthCodeURL(r.Context(), state, nonce, pkceChallenge(verifier))
+		if err != nil {
+			slog.Error("oidc_discovery_failed", "provider", provider.Name, "error", err)
+			response.Error(w, apperr.ErrProviderUnavailable)
+			return
+		}
+
+		if err := identities.CreateLoginState(r.Context(), utils.HashToken(state), &model.OIDCLoginState{
+			Provider:     provider.Name,
+			Nonce:        nonce,
+			CodeVerifier: verifier,
+			ExpiresAt:    time.Now().Add(config.Get().OIDC.StateTTL),
+		}); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: model.OIDCStart{AuthorizationURL: authURL, State: state},
+		})
+	}
+}
+
+// @Summary Complete external sign-in
+// @Description Exchanges the code the identity provider returned for a verified ID token and signs the linked user in. First-time users are linked by verified email, or get a new account. Local 2FA still applies.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name from OIDC_PROVIDERS"
+// @Param request body schema.OIDCCallbackRequest true "Code and state from the provider redirect"
+// @Success 200 {object} response.LoginResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse "Unknown provider"
+// @Failure 409 {object} response.ErrorResponse "Email belongs to a deleted account"
+// @Router /api/v1/public/auth/oidc/{provider}/callback [post]
+func OIDCCallbackHandler(repo repository.IAuthRepository, identities repository.IIdentityRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		provider, ok := oidc.Default().Get(r.PathValue("provider"))
+		if !ok {
+			response.Error(w, apperr.ErrUnknownProvider)
+			return
+		}
+
+		var req schema.OIDCCallbackRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		state, err := identities.ConsumeLoginState(r.Context(), utils.HashToken(req.State))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if state.Provider != provider.Name {
+			response.Error(w, apperr.ErrInvalidLoginState)
+			return
+		}
+
+		idToken, err := provider.Exchange(r.Context(), req.Code, state.CodeVerifier, state.Nonce)
+		if err != nil {
+			slog.Warn("oidc_login_failed", "provider", provider.Name, "error", err)
+			response.Error(w, apperr.ErrExternalLoginFailed)
+			return
+		}
+
+		user, err := resolveExternalUser(r.Context(), repo, identities, provider.Name, idToken)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		event := newLoginEvent(r, user.Email)
+
+		if err := checkAccountStatus(user); err != nil {
+			event.FailureReason = failureReason(err)
+			recordLoginEvent(r, repo, event)
+			response.Error(w, err)
+			return
+		}
+
+		completeLogin(w, r, repo, user, event)
+	}
+}
+
+// resolveExternalUser finds the user an ID token belongs to. An identity seen
+// before maps to its user. Otherwise the provider must vouch for the email:
+// an existing account with that email is linked, or a new one is created.
+func resolveExternalUser(ctx context.Context, repo repository.IAuthRepository, identities repository.IIdentityRepository, provider string, token *oidc.IDToken) (*model.User, error) {
+	user, err := identities.FindUser(ctx, provider, token.Subject)
+	if err != apperr.ErrNotFound {
+		return user, err
+	}
+
+	if token.Email == "" || !token.EmailVerified {
+		return nil, apperr.ErrExternalEmailUnverified
+	}
+
+	existing, err := repo.FindByEmail(ctx, token.Email)
+	switch err {
+	case nil:
+		if err := identities.Link(ctx, existing.ID, provider, token.Subject, token.Email); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	case apperr.ErrNotFound:
+	default:
+		return nil, err
+	}
+
+	// Nobody knows this password; a local one can be set via forgot-password
+	secret, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := utils.HashPassword(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user = &model.User{
+		Username: externalUsername(token),
+		Email:    token.Email,
+	}
+	if err := identities.CreateUser(ctx, user, passwordHash, provider, token.Subject); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func externalUsername(token *oidc.IDToken) string {
+	name := token.PreferredUsername
+	if name == "" {
+		name = token.Name
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(token.Email, "@")
+	}
+	return truncate(name, 100)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/oidc"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeAuthRepo keeps users in memory. Methods the test does not need panic
+// through the nil embedded interface.
+type fakeAuthRepo struct {
+	repository.IAuthRepository
+	users []*model.User
+}
+
+func (r *fakeAuthRepo) FindByEmail(_ context.Context, email string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, apperr.ErrNotFound
+}
+
+func (r *fakeAuthRepo) FindByID(_ context.Context, id int64) (*model.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, apperr.ErrNotFound
+}
+
+type fakeIdentity struct {
+	userID   int64
+	provider string
+	subject  string
+}
+
+type fakeIdentityRepo struct {
+	repository.IIdentityRepository
+	auth       *fakeAuthRepo
+	identities []fakeIdentity
+}
+
+func (r *fakeIdentityRepo) FindUser(ctx context.Context, provider, subject string) (*model.User, error) {
+	for _, i := range r.identities {
+		if i.provider == provider && i.subject == subject {
+			return r.auth.FindByID(ctx, i.userID)
+		}
+	}
+	return nil, apperr.ErrNotFound
+}
+
+func (r *fakeIdentityRepo) Link(_ context.Context, userID int64, provider, subject, _ string) error {
+	r.identities = append(r.identities, fakeIdentity{userID, provider, subject})
+	return nil
+}
+
+func (r *fakeIdentityRepo) CreateUser(_ context.Context, user *model.User, _ string, provider, subject string) error {
+	user.ID = int64(len(r.auth.users) + 1)
+	r.auth.users = append(r.auth.users, user)
+	r.identities = append(r.identities, fakeIdentity{user.ID, provider, subject})
+	return nil
+}
+
+func TestResolveExternalUser(t *testing.T) {
+	utils.SetPasswordHasher(utils.BcryptHasher{Cost: bcrypt.MinCost})
+	t.Cleanup(func() { utils.SetPasswordHasher(nil) })
+
+	idToken := func(subject, email string, verified bool) *oidc.IDToken {
+		return &oidc.IDToken{
+			Email:             email,
+			EmailVerified:     verified,
+			PreferredUsername: "alice.corp",
+			RegisteredClaims:  jwt.RegisteredClaims{Subject: subject},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		token      *oidc.IDToken
+		wantErr    error
+		wantUserID int64
+		wantUsers  int // users in the store afterwards
+	}{
+		{
+			name:       "known identity",
+			token:      idToken("sub-known", "changed@example.com", false),
+			wantUserID: 1,
+			wantUsers:  2,
+		},
+		{
+			name:      "unverified email",
+			token:     idToken("sub-new", "bob@example.com", false),
+			wantErr:   apperr.ErrExternalEmailUnverified,
+			wantUsers: 2,
+		},
+		{
+			name:      "no email",
+			token:     idToken("sub-new", "", true),
+			wantErr:   apperr.ErrExternalEmailUnverified,
+			wantUsers: 2,
+		},
+		{
+			name:       "links the account with the same email",
+			token:      idToken("sub-new", "bob@example.com", true),
+			wantUserID: 2,
+			wantUsers:  2,
+		},
+		{
+			name:       "creates an account",
+			token:      idToken("sub-new", "carol@example.com", true),
+			wantUserID: 3,
+			wantUsers:  3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := &fakeAuthRepo{users: []*model.User{
+				{ID: 1, Username: "alice", Email: "alice@example.com"},
+				{ID: 2, Username: "bob", Email: "bob@example.com"},
+			}}
+			identities := &fakeIdentityRepo{
+				auth:       auth,
+				identities: []fakeIdentity{{userID: 1, provider: "corp", subject: "sub-known"}},
+			}
+
+			user, err := resolveExternalUser(context.Background(), auth, identities, "corp", tc.token)
+			if err != tc.wantErr {
+				t.Fatalf("error = %v, want %v", err, tc.wantErr)
+			}
+			if len(auth.users) != tc.wantUsers {
+				t.Errorf("users = %d, want %d", len(auth.users), tc.wantUsers)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+
+			if user.ID != tc.wantUserID {
+				t.Errorf("user = %d, want %d", user.ID, tc.wantUserID)
+			}
+			linked, _ := identities.FindUser(context.Background(), "corp", tc.token.Subject)
+			if linked == nil || linked.ID != tc.wantUserID {
+				t.Errorf("identity resolves to %v, want user %d", linked, tc.wantUserID)
+			}
+		})
+	}
+
+	t.Run("new account takes the preferred username", func(t *testing.T) {
+		auth := &fakeAuthRepo{}
+		identities := &fakeIdentityRepo{auth: auth}
+
+		user, err := resolveExternalUser(context.Background(), auth, identities, "corp", idToken("sub-1", "dave@example.com", true))
+		if err != nil {
+			t.Fatalf("resolveExternalUser: %v", err)
+		}
+		if user.Username != "alice.corp" || user.Email != "dave@example.com" {
+			t.Errorf("user = %+v", user)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/mailer"
+	"github.com/lakhan-purohit/net-http/internal/pkg/password"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Forgot password
+// @Description Emails a one-time reset code. The response is the same whether or not the account exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/forgot-password [post]
+func ForgotPasswordHandler(repo repository.IAuthRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.ForgotPasswordRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		const sent = "If the account exists, a reset code has been sent"
+
+		user, err := repo.FindByEmail(r.Context(), req.Email)
+		if err == apperr.ErrNotFound {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		cfg := config.Get().Auth
+		limited, err := sendLimited(r.Context(), userTokens, user.ID, constants.TokenPurposePasswordReset, cfg.PasswordResetRateLimit, cfg.PasswordResetRateWindow)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if limited {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+
+		ttl := cfg.PasswordResetTTL
+		code, err := issueOTP(r.Context(), userTokens, user.ID, constants.TokenPurposePasswordReset, "", ttl)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		deliver(mailer.Message{
+			To:      user.Email,
+			Subject: "Your password reset code",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nYour password reset code is %s. It expires in %s.\n\nIf you did not ask to reset your password you can ignore this email.\n",
+				user.Username, code, ttl,
+			),
+		})
+
+		response.Success(response.SendParams{W: w, Message: sent})
+	}
+}
+
+// @Summary Reset password
+// @Description Sets a new password using the emailed code. All existing sessions are logged out.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.ResetPasswordRequest true "Reset code and new password"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.PasswordPolicyErrorResponse
+// @Router /api/v1/public/auth/reset-password [post]
+func ResetPasswordHandler(
+	repo repository.IAuthRepository,
+	userTokens repository.IUserTokenRepository,
+	tokens repository.ITokenRepository,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.ResetPasswordRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := repo.FindByEmail(r.Context(), req.Email)
+		if err == apperr.ErrNotFound {
+			response.Error(w, apperr.ErrInvalidCode)
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		t, err := verifyOTP(r.Context(), userTokens, user.ID, constants.TokenPurposePasswordReset, req.Code)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := checkPasswordPolicy(r.Context(), repo, user, req.Password); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := repo.ResetPassword(r.Context(), user.ID, t.ID, req.Password); err != nil {
+			if err == apperr.ErrInvalidToken {
+				err = apperr.ErrInvalidCode
+			}
+			response.Error(w, err)
+			return
+		}
+
+		// Whoever knew the old password must not stay logged in
+		if err := revokeAllSessions(r.Context(), tokens, user.ID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Password has been reset",
+		})
+	}
+}
+
+// checkPasswordPolicy validates a password the user is about to set. Users
+// that do not exist yet (ID 0) have no history to compare against.
+func checkPasswordPolicy(ctx context.Context, repo repository.IPasswordHistory, user *model.User, plain string) error {
+	policy := password.Default()
+
+	candidate := password.Candidate{
+		Password: plain,
+		Username: user.Username,
+		Email:    user.Email,
+	}
+	if user.ID != 0 && policy.History > 0 {
+		previous, err := repo.PasswordHistory(ctx, user.ID, policy.History)
+		if err != nil {
+			return err
+		}
+		candidate.PreviousHashes = previous
+	}
+
+	return policy.Validate(ctx, candidate)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+)
+
+// @Summary List active sessions
+// @Description Lists the devices the current user is logged in on.
+// @Tags User
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.SessionListResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /api/v1/private/user/sessions [get]
+func SessionListHandler(tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		sessions, err := tokens.ListSessions(r.Context(), claims.UserID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		for _, s := range sessions {
+			s.Current = s.ID == claims.SessionID
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: sessions,
+		})
+	}
+}
+
+// @Summary Revoke a session
+// @Description Logs the current user out of one device. Its refresh tokens are revoked and its access token stops working at once.
+// @Tags User
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/user/sessions/{id} [delete]
+func SessionRevokeHandler(tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		sessionID := r.PathValue("id")
+		if err := tokens.RevokeSession(r.Context(), claims.UserID, sessionID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := revokeSessionAccess(r.Context(), sessionID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Session revoked",
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/revocation"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+)
+
+// newSession signs a token pair for the user and builds the refresh token row
+// that tracks it, tagged with the device the request came from. An empty
+// familyID starts a new family (i.e. a new login); rotation passes the family
+// of the token being replaced.
+func newSession(r *http.Request, user *model.User, familyID string) (*utils.TokenPair, *model.RefreshToken, error) {
+	return issueSession(r, utils.Claims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		UUID:   user.UUID,
+	}, familyID)
+}
+
+// newDelegatedSession is newSession for an OAuth client acting for the user.
+// The tokens carry only the user identity, the client and the granted scope.
+func newDelegatedSession(r *http.Request, user *model.User, clientID, scope, familyID string) (*utils.TokenPair, *model.RefreshToken, error) {
+	return issueSession(r, utils.Claims{
+		UserID:   user.ID,
+		UUID:     user.UUID,
+		ClientID: clientID,
+		Scope:    scope,
+	}, familyID)
+}
+
+func issueSession(r *http.Request, claims utils.Claims, familyID string) (*utils.TokenPair, *model.RefreshToken, error) {
+	if familyID == "" {
+		familyID = utils.UUID()
+	}
+	claims.SessionID = familyID
+
+	pair, err := utils.NewJWT().GeneratePair(claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pair, &model.RefreshToken{
+		TokenID:   pair.RefreshID,
+		FamilyID:  familyID,
+		UserID:    claims.UserID,
+		UserAgent: truncate(r.UserAgent(), 255),
+		IPAddress: request.ClientIP(r),
+		ClientID:  claims.ClientID,
+		Scope:     claims.Scope,
+		ExpiresAt: pair.RefreshExpiresAt,
+	}, nil
+}
+
+// loadRefreshToken returns the persisted state of a parsed refresh token. A
+// replay of an already rotated token is treated as a leak and revokes the
+// whole family.
+func loadRefreshToken(ctx context.Context, tokens repository.ITokenRepository, tokenID string) (*model.RefreshToken, error) {
+	current, err := tokens.FindByTokenID(ctx, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if current.RevokedAt != nil {
+		return nil, apperr.ErrTokenRevoked
+	}
+	if current.UsedAt != nil {
+		_ = tokens.RevokeFamily(ctx, current.FamilyID)
+		return nil, apperr.ErrTokenReused
+	}
+	return current, nil
+}
+
+// rotateRefreshToken swaps current for next, revoking the family if another
+// request rotated current first.
+func rotateRefreshToken(ctx context.Context, tokens repository.ITokenRepository, current, next *model.RefreshToken) error {
+	err := tokens.Rotate(ctx, current.TokenID, next)
+	if err == apperr.ErrTokenReused {
+		_ = tokens.RevokeFamily(ctx, current.FamilyID)
+	}
+	return err
+}
+
+// newLoginEvent captures who/where a login attempt came from
+func newLoginEvent(r *http.Request, email string) *model.LoginEvent {
+	requestID, _ := r.Context().Value(constants.RequestIDContextKey).(string)
+
+	return &model.LoginEvent{
+		Email:     email,
+		IPAddress: request.ClientIP(r),
+		UserAgent: truncate(r.UserAgent(), 255),
+		RequestID: requestID,
+	}
+}
+
+// recordLoginEvent stores the attempt; failures are logged and never block the login
+func recordLoginEvent(r *http.Request, repo repository.IAuthRepository, e *model.LoginEvent) {
+	if err := repo.RecordLoginEvent(r.Context(), e); err != nil {
+		slog.Error("login_event_failed", "request_id", e.RequestID, "error", err)
+	}
+}
+
+// truncate cuts s to at most max characters, the unit utf8mb4 VARCHAR columns
+// are sized in. It never splits a multi-byte character and drops invalid UTF-8,
+// which strict SQL mode would refuse to store.
+func truncate(s string, max int) string {
+	s = strings.ToValidUTF8(s, "")
+	for i := range s {
+		if max == 0 {
+			return s[:i]
+		}
+		max--
+	}
+	return s
+}
+
+// revokeAllSessions logs the user out everywhere: every refresh token is revoked
+// and every access token issued up to now is rejected until it would have expired.
+func revokeAllSessions(ctx context.Context, tokens repository.ITokenRepository, userID int64) error {
+	if err := tokens.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// JWT iat has second precision
+	return revokeAccessTokens(ctx, userID, time.Now().Truncate(time.Second))
+}
+
+// revokeAccessTokens rejects every access token of the user issued before
+// cutoff. Tokens issued in the cutoff second itself stay valid, so a caller
+// that takes the cutoff first can hand out a fresh pair right after.
+func revokeAccessTokens(ctx context.Context, userID int64, cutoff time.Time) error {
+	return revocation.Default().RevokeUser(ctx, userID, cutoff, cutoff.Add(utils.NewJWT().AccessTTL()))
+}
+
+// revokeSessionAccess rejects every access token of one session. Revoking the
+// refresh family alone would leave them valid until they expire.
+func revokeSessionAccess(ctx context.Context, sessionID string) error {
+	return revocation.Default().RevokeSession(ctx, sessionID, time.Now().Add(utils.NewJWT().AccessTTL()))
+}
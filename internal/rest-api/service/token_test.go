@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{name: "short", in: "curl/8.0", max: 255, want: "curl/8.0"},
+		{name: "exact", in: "abc", max: 3, want: "abc"},
+		{name: "ascii", in: "abcdef", max: 3, want: "abc"},
+		{name: "counts characters not bytes", in: "Jürgen Müller", max: 8, want: "Jürgen M"},
+		{name: "never splits a character", in: "日本語のユーザー", max: 3, want: "日本語"},
+		{name: "four byte characters", in: "a😀😀b", max: 2, want: "a😀"},
+		{name: "drops invalid bytes", in: "ab\xffcd", max: 3, want: "abc"},
+		{name: "zero", in: "abc", max: 0, want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := truncate(tc.in, tc.max)
+			if got != tc.want {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q is not valid UTF-8", tc.in, tc.max, got)
+			}
+		})
+	}
+}
@@ -1,30 +1,54 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/pagination"
+	"github.com/lakhan-purohit/net-http/internal/pkg/rbac"
 	"github.com/lakhan-purohit/net-http/internal/pkg/request"
 	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
 	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
 )
 
 // @Summary Get user list
+// @Description Users sorted by `sort` (default `-created_at`; ties are broken by id) and narrowed down by the optional filters, which are echoed back under `filters`. When sorted by created_at alone, follow `pagination.next_cursor` / `prev_cursor` rather than offsets: cursors stay stable while users sign up, and are only valid with the same filters.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param limit query int false "Limit for pagination" default(10)
 // @Param offset query int false "Offset for pagination" default(0)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page; only with sort=created_at or -created_at, not with offset"
+// @Param include_total query bool false "Also count every matching user"
+// @Param sort query string false "Comma-separated fields out of created_at, username, email and status; prefix with - for descending" example(-created_at,username)
+// @Param status query int false "Account status" Enums(0, 1, 2, 3, 4)
+// @Param email query string false "Email address prefix"
+// @Param created_from query string false "Created at or after (RFC 3339, or YYYY-MM-DD in UTC)"
+// @Param created_to query string false "Created before (RFC 3339, or YYYY-MM-DD in UTC)"
+// @Param q query string false "Full-text search on username and email; every word must match as a prefix"
 // @Success 200 {object} response.UserListResponse
+// @Failure 400 {object} response.ErrorResponse
 // @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Router /api/v1/private/user/get-list [get]
 func UserGetListHandler(repo repository.IUserRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		// Parse pagination
-		var pg request.PaginationRequest
-		if err := request.BindQuery(r, &pg); err != nil {
+		filter, pg, err := bindUserFilter(r)
+		if err != nil {
 			response.BadRequest(response.SendParams{
 				W:       w,
 				Message: err.Error(),
@@ -32,12 +56,7 @@ func UserGetListHandler(repo repository.IUserRepository) http.HandlerFunc {
 			return
 		}
 
-		// Defaults
-		if pg.Limit == 0 {
-			pg.Limit = 10
-		}
-
-		users, err := repo.GetList(r.Context(), pg.Limit, pg.Offset)
+		users, page, err := listUsers(r.Context(), repo, filter, pg)
 		if err != nil {
 			response.InternalError(response.SendParams{
 				W:       w,
@@ -48,7 +67,7 @@ func UserGetListHandler(repo repository.IUserRepository) http.HandlerFunc {
 
 		response.Success(response.SendParams{
 			W:    w,
-			Data: users,
+			Data: response.Page[*model.User]{Items: users, Filters: filter, Pagination: page},
 		})
 	}
 }
@@ -56,20 +75,31 @@ func UserGetListHandler(repo repository.IUserRepository) http.HandlerFunc {
 // UserGetFullListHandler demonstrates a "Complex API" fetch
 // It fetches users and their stats, showing how to avoid the N+1 problem.
 // @Summary Get user list with statistics (Complex Example)
+// @Description Takes the same sorting, filter and pagination parameters as get-list.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param limit query int false "Limit" default(10)
 // @Param offset query int false "Offset" default(0)
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page; only with sort=created_at or -created_at, not with offset"
+// @Param include_total query bool false "Also count every matching user"
+// @Param sort query string false "Comma-separated fields out of created_at, username, email and status; prefix with - for descending" example(-created_at,username)
+// @Param status query int false "Account status" Enums(0, 1, 2, 3, 4)
+// @Param email query string false "Email address prefix"
+// @Param created_from query string false "Created at or after (RFC 3339, or YYYY-MM-DD in UTC)"
+// @Param created_to query string false "Created before (RFC 3339, or YYYY-MM-DD in UTC)"
+// @Param q query string false "Full-text search on username and email"
 // @Success 200 {object} response.UserFullListResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /api/v1/private/user/get-full-list [get]
 func UserGetFullListHandler(repo repository.IUserRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		
-		var pg request.PaginationRequest
-		if err := request.BindQuery(r, &pg); err != nil {
+		filter, pg, err := bindUserFilter(r)
+		if err != nil {
 			response.BadRequest(response.SendParams{
 				W:       w,
 				Message: err.Error(),
@@ -77,13 +107,8 @@ func UserGetFullListHandler(repo repository.IUserRepository) http.HandlerFunc {
 			return
 		}
 
-		// Defaults
-		if pg.Limit == 0 {
-			pg.Limit = 10
-		}
-
 		// 1. Fetch Users
-		users, err := repo.GetList(r.Context(), pg.Limit, pg.Offset)
+		users, page, err := listUsers(r.Context(), repo, filter, pg)
 		if err != nil {
 			response.InternalError(response.SendParams{W: w, Message: err.Error()})
 			return
@@ -113,7 +138,332 @@ func UserGetFullListHandler(repo repository.IUserRepository) http.HandlerFunc {
 
 		response.Success(response.SendParams{
 			W:    w,
-			Data: fullList,
+			Data: response.Page[model.UserWithStats]{Items: fullList, Filters: filter, Pagination: page},
 		})
 	}
 }
+
+// bindUserFilter reads the sorting, filter and pagination parameters shared
+// by the user lists
+func bindUserFilter(r *http.Request) (*model.UserFilter, request.PaginationRequest, error) {
+	var q schema.UserListQuery
+	if err := request.BindQuery(r, &q); err != nil {
+		return nil, q.PaginationRequest, err
+	}
+
+	sort, err := request.ParseSort(q.Sort, repository.UserSortFields...)
+	if err != nil {
+		return nil, q.PaginationRequest, err
+	}
+	if len(sort) == 0 {
+		sort = []string{"-created_at"}
+	}
+
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedTo.After(*q.CreatedFrom) {
+		return nil, q.PaginationRequest, errors.New("created_to must be after created_from")
+	}
+
+	filter := &model.UserFilter{
+		Status:      q.Status,
+		Email:       strings.TrimSpace(q.Email),
+		CreatedFrom: q.CreatedFrom,
+		CreatedTo:   q.CreatedTo,
+		Query:       strings.TrimSpace(q.Q),
+		Sort:        sort,
+		Limit:       q.Limit,
+		Offset:      q.Offset,
+	}
+
+	if q.Cursor != "" {
+		if !repository.UserCursorSort(sort) {
+			return nil, q.PaginationRequest, errors.New("cursor needs sort=created_at or sort=-created_at")
+		}
+		if filter.Cursor, err = pagination.Decode(q.Cursor, pagination.Scope(filter)); err != nil {
+			return nil, q.PaginationRequest, err
+		}
+	}
+
+	// Defaults
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+	return filter, q.PaginationRequest, nil
+}
+
+// listUsers fetches the page of users selected by filter along with the
+// metadata to reach the pages around it. Cursors are only issued for the
+// orders UserCursorSort accepts; other orders page by offset.
+func listUsers(
+	ctx context.Context,
+	repo repository.IUserRepository,
+	filter *model.UserFilter,
+	pg request.PaginationRequest,
+) ([]*model.User, response.PageInfo, error) {
+	page := response.PageInfo{Limit: filter.Limit, Offset: filter.Offset}
+
+	// One row beyond the page tells whether there is another one
+	probe := *filter
+	probe.Limit++
+	users, err := repo.GetList(ctx, &probe)
+	if err != nil {
+		return nil, page, err
+	}
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	beyond := len(users) > filter.Limit
+	if beyond {
+		if backward {
+			users = users[1:]
+		} else {
+			users = users[:filter.Limit]
+		}
+	}
+	if users == nil {
+		users = []*model.User{}
+	}
+
+	// A backward page was reached from a later one
+	page.HasMore = beyond || backward
+	hasPrev := filter.Offset > 0 || (filter.Cursor != nil && !backward) || (backward && beyond)
+
+	if repository.UserCursorSort(filter.Sort) && len(users) > 0 {
+		scope := pagination.Scope(filter)
+		if page.HasMore {
+			page.NextCursor = userCursor(users[len(users)-1], false, scope)
+		}
+		if hasPrev {
+			page.PrevCursor = userCursor(users[0], true, scope)
+		}
+	}
+
+	if pg.IncludeTotal {
+		total, err := repo.CountList(ctx, filter)
+		if err != nil {
+			return nil, page, err
+		}
+		page.Total = &total
+	}
+
+	return users, page, nil
+}
+
+func userCursor(u *model.User, backward bool, scope string) string {
+	c := pagination.Cursor{ID: u.ID, Backward: backward, Scope: scope}
+	if u.CreatedAt != nil {
+		c.CreatedAt = *u.CreatedAt
+	}
+	return c.Encode()
+}
+
+// @Summary Get the current user
+// @Tags User
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.UserResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/user/users/me [get]
+func UserMeHandler(repo repository.IUserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		user, err := repo.FindByUUID(r.Context(), claims.UUID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: user,
+		})
+	}
+}
+
+// @Summary Get a user
+// @Description Users may read their own account; user managers (admins) any account.
+// @Tags User
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} response.UserResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/user/users/{uuid} [get]
+func UserGetHandler(repo repository.IUserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := findAccessibleUser(r, repo)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: user,
+		})
+	}
+}
+
+// @Summary Update a user
+// @Description Changes the username and/or avatar. Send JSON, or multipart/form-data to upload a new avatar. Email and password have their own endpoints.
+// @Tags User
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Param request body schema.UpdateUserRequest true "Fields to change"
+// @Success 200 {object} response.UserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/user/users/{uuid} [patch]
+func UserUpdateHandler(repo repository.IUserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := findAccessibleUser(r, repo)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		var req schema.UpdateUserRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		if req.Username == "" && req.Avatar == nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: "Nothing to update",
+			})
+			return
+		}
+
+		var avatar string
+		if req.Avatar != nil {
+			saved, err := utils.SaveSingle(req.Avatar, constants.UserAvatarDir, "image/")
+			if err != nil {
+				response.BadRequest(response.SendParams{
+					W:       w,
+					Message: err.Error(),
+				})
+				return
+			}
+			avatar = saved.Name
+		}
+
+		if err := repo.UpdateProfile(r.Context(), user.ID, req.Username, avatar); err != nil {
+			// Nothing references the new file yet
+			if avatar != "" {
+				removeAvatar(avatar)
+			}
+			response.Error(w, err)
+			return
+		}
+
+		if avatar != "" && user.Avatar != "" {
+			removeAvatar(user.Avatar)
+		}
+
+		user, err = repo.FindByUUID(r.Context(), user.UUID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "User updated",
+			Data:    user,
+		})
+	}
+}
+
+// @Summary Delete a user
+// @Description Deletes an account and logs it out everywhere. Users may delete their own account; user managers (admins) any account. The account can be restored by an admin until it is purged after ACCOUNT_DELETED_RETENTION.
+// @Tags User
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/user/users/{uuid} [delete]
+func UserDeleteHandler(repo repository.IUserRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		// An impersonating admin must use their own account to delete users
+		if _, impersonated := middleware.ActorFromContext(r.Context()); impersonated {
+			response.Error(w, apperr.ErrImpersonationForbidden)
+			return
+		}
+
+		user, err := findAccessibleUser(r, repo)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// No fresh pair is handed out, so tokens from this very second go too
+		if err := revokeAccessTokens(r.Context(), user.ID, time.Now().Truncate(time.Second).Add(time.Second)); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := repo.Delete(r.Context(), user.ID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		// The avatar is kept for a restore; the purge removes it
+		if err := tokens.RevokeAllForUser(r.Context(), user.ID); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "User deleted",
+		})
+	}
+}
+
+// findAccessibleUser loads the user named by the {uuid} path value if the
+// caller may act on it: their own account, or any account with PermUserManage
+func findAccessibleUser(r *http.Request, repo repository.IUserRepository) (*model.User, error) {
+	claims, ok := middleware.ClaimsFromContext(r.Context())
+	if !ok {
+		return nil, apperr.ErrUnauthorized
+	}
+
+	uuid := r.PathValue("uuid")
+	if uuid != claims.UUID && !rbac.Can(claims.Role, rbac.PermUserManage) {
+		return nil, apperr.ErrPermissionDenied
+	}
+
+	return repo.FindByUUID(r.Context(), uuid)
+}
+
+// removeAvatar deletes a replaced or orphaned avatar file; failures are only logged
+func removeAvatar(name string) {
+	path := filepath.Join(constants.UserAvatarDir, filepath.Base(name))
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("avatar_remove_failed", "path", path, "error", err)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+)
+
+// RunUserPurge hard-deletes accounts that were deleted more than
+// cfg.DeletedRetention ago, together with their uploaded files, once at start
+// and then every cfg.PurgeInterval. It never returns.
+func RunUserPurge(repo repository.IUserRepository, cfg config.AccountConfig) {
+	purgeDeletedUsers(repo, cfg)
+
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purgeDeletedUsers(repo, cfg)
+	}
+}
+
+func purgeDeletedUsers(repo repository.IUserRepository, cfg config.AccountConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	before := time.Now().Add(-cfg.DeletedRetention)
+	for {
+		users, err := repo.PurgeDeleted(ctx, before, cfg.PurgeBatchSize)
+
+		// Rows already gone lose their files even if the batch failed halfway
+		for _, u := range users {
+			if u.Avatar != "" {
+				removeAvatar(u.Avatar)
+			}
+			slog.Info("user_purged", "user", u.UUID, "deleted_at", u.DeletedAt)
+		}
+
+		if err != nil {
+			slog.Error("user_purge_failed", "error", err)
+			return
+		}
+		if len(users) < cfg.PurgeBatchSize {
+			return
+		}
+	}
+}
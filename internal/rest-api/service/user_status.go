@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/middleware"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// statusTransitions lists the statuses an admin may move an account to from
+// each status. Pending accounts normally become active by verifying their
+// email; nothing moves back to pending. Suspended and banned may be set
+// again to change their expiry.
+var statusTransitions = map[int][]int{
+	constants.UserStatusPending:   {constants.UserStatusActive, constants.UserStatusBanned},
+	constants.UserStatusActive:    {constants.UserStatusInactive, constants.UserStatusSuspended, constants.UserStatusBanned},
+	constants.UserStatusInactive:  {constants.UserStatusActive, constants.UserStatusBanned},
+	constants.UserStatusSuspended: {constants.UserStatusActive, constants.UserStatusSuspended, constants.UserStatusBanned},
+	constants.UserStatusBanned:    {constants.UserStatusActive, constants.UserStatusBanned},
+}
+
+// temporaryStatuses may carry an expiry, after which the account is active again
+var temporaryStatuses = []int{constants.UserStatusSuspended, constants.UserStatusBanned}
+
+// @Summary Change a user's status
+// @Description Activates, deactivates (0), suspends (4) or bans (3) an account with a mandatory reason. Suspensions and bans may carry expires_at, after which the account is active again. Only transitions allowed from the current status are accepted; any status other than active logs the user out everywhere. Every change is kept in the status history.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Param request body schema.ChangeUserStatusRequest true "New status, reason and optional expiry"
+// @Success 200 {object} response.UserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Own account"
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Transition not allowed from the current status"
+// @Router /api/v1/private/admin/users/{uuid}/status [post]
+func AdminChangeUserStatusHandler(users repository.IUserRepository, tokens repository.ITokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		claims, ok := middleware.ClaimsFromContext(r.Context())
+		if !ok {
+			response.Error(w, apperr.ErrUnauthorized)
+			return
+		}
+
+		var req schema.ChangeUserStatusRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		to := *req.Status
+		if req.ExpiresAt != nil {
+			if !slices.Contains(temporaryStatuses, to) {
+				response.BadRequest(response.SendParams{
+					W:       w,
+					Message: "expires_at is only allowed when suspending or banning",
+				})
+				return
+			}
+			if !req.ExpiresAt.After(time.Now()) {
+				response.BadRequest(response.SendParams{
+					W:       w,
+					Message: "expires_at must be in the future",
+				})
+				return
+			}
+		}
+
+		user, err := users.FindByUUID(r.Context(), r.PathValue("uuid"))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if user.ID == claims.UserID {
+			response.Error(w, apperr.ErrStatusChangeNotAllowed)
+			return
+		}
+		if !slices.Contains(statusTransitions[user.Status], to) {
+			response.Error(w, apperr.ErrInvalidStatusTransition)
+			return
+		}
+
+		// Taken before the change so tokens issued up to now are covered
+		cutoff := time.Now().Truncate(time.Second).Add(time.Second)
+
+		err = users.WithTransaction(r.Context(), func(tx *sql.Tx) error {
+			if err := users.SetStatusTx(r.Context(), tx, user.ID, user.Status, to, req.ExpiresAt); err != nil {
+				return err
+			}
+			if err := users.AddStatusChangeTx(r.Context(), tx, &model.StatusChange{
+				UserID:      user.ID,
+				FromStatus:  user.Status,
+				ToStatus:    to,
+				Reason:      req.Reason,
+				ExpiresAt:   req.ExpiresAt,
+				ChangedByID: &claims.UserID,
+			}); err != nil {
+				return err
+			}
+			if to == constants.UserStatusActive {
+				return nil
+			}
+			return tokens.RevokeAllForUserTx(r.Context(), tx, user.ID)
+		})
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if to != constants.UserStatusActive {
+			if err := revokeAccessTokens(r.Context(), user.ID, cutoff); err != nil {
+				response.Error(w, err)
+				return
+			}
+		}
+
+		requestID, _ := r.Context().Value(constants.RequestIDContextKey).(string)
+		slog.Warn("user_status_changed",
+			"request_id", requestID,
+			"actor", claims.UUID,
+			"user", user.UUID,
+			"from", user.Status,
+			"to", to,
+			"expires_at", req.ExpiresAt,
+		)
+
+		user.Status = to
+		user.StatusUntil = req.ExpiresAt
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "User status changed",
+			Data:    user,
+		})
+	}
+}
+
+// @Summary Get a user's status history
+// @Description Every status change of the account, newest first: who made it (empty when a temporary status expired), why, and until when.
+// @Tags Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param uuid path string true "User UUID"
+// @Success 200 {object} response.StatusHistoryResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /api/v1/private/admin/users/{uuid}/status-history [get]
+func AdminUserStatusHistoryHandler(users repository.IUserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		user, err := users.FindByUUID(r.Context(), r.PathValue("uuid"))
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		history, err := users.StatusHistory(r.Context(), user.ID)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if history == nil {
+			history = []*model.StatusChange{}
+		}
+
+		response.Success(response.SendParams{
+			W:    w,
+			Data: history,
+		})
+	}
+}
+
+// RunStatusExpiry makes accounts whose temporary ban or suspension has run
+// out active again every interval, recording it in their history. Logins do
+// not wait for it (see checkAccountStatus); it keeps the stored status
+// accurate. It never returns.
+func RunStatusExpiry(users repository.IUserRepository, cfg config.AccountConfig) {
+	ticker := time.NewTicker(cfg.StatusExpiryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		expireStatuses(users)
+	}
+}
+
+// statusExpiryBatchSize is how many accounts one ExpireStatuses call handles
+const statusExpiryBatchSize = 100
+
+func expireStatuses(users repository.IUserRepository) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	for {
+		expired, err := users.ExpireStatuses(ctx, statusExpiryBatchSize)
+		for _, u := range expired {
+			slog.Info("user_status_expired", "user", u.UUID, "from", u.Status, "until", u.StatusUntil)
+		}
+
+		if err != nil {
+			slog.Error("user_status_expiry_failed", "error", err)
+			return
+		}
+		if len(expired) < statusExpiryBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+)
+
+func TestStatusTransitions(t *testing.T) {
+	const (
+		inactive  = constants.UserStatusInactive
+		active    = constants.UserStatusActive
+		pending   = constants.UserStatusPending
+		banned    = constants.UserStatusBanned
+		suspended = constants.UserStatusSuspended
+	)
+	all := []int{inactive, active, pending, banned, suspended}
+
+	allowed := map[int][]int{
+		pending:   {active, banned},
+		active:    {inactive, banned, suspended},
+		inactive:  {active, banned},
+		suspended: {active, banned, suspended},
+		banned:    {active, banned},
+	}
+
+	for _, from := range all {
+		for _, to := range all {
+			want := slices.Contains(allowed[from], to)
+			if got := slices.Contains(statusTransitions[from], to); got != want {
+				t.Errorf("%d -> %d allowed = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+
+	for from, targets := range statusTransitions {
+		if slices.Contains(targets, pending) {
+			t.Errorf("%d -> pending is allowed", from)
+		}
+	}
+	if len(statusTransitions[99]) != 0 {
+		t.Error("unknown status has transitions")
+	}
+}
+
+func TestCheckAccountStatus(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		status   int
+		until    *time.Time
+		want     *apperr.AppError
+		hasUntil bool
+	}{
+		{name: "active", status: constants.UserStatusActive},
+		{name: "pending", status: constants.UserStatusPending, want: apperr.ErrAccountPending},
+		{name: "inactive", status: constants.UserStatusInactive, want: apperr.ErrAccountInactive},
+		{name: "banned", status: constants.UserStatusBanned, want: apperr.ErrAccountBanned},
+		{name: "banned until later", status: constants.UserStatusBanned, until: &future, want: apperr.ErrAccountBanned, hasUntil: true},
+		{name: "ban expired", status: constants.UserStatusBanned, until: &past},
+		{name: "suspended until later", status: constants.UserStatusSuspended, until: &future, want: apperr.ErrAccountSuspended, hasUntil: true},
+		{name: "suspension expired", status: constants.UserStatusSuspended, until: &past},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkAccountStatus(&model.User{Status: tc.status, StatusUntil: tc.until})
+
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("error = %v, want nil", err)
+				}
+				return
+			}
+
+			var appErr *apperr.AppError
+			if !errors.As(err, &appErr) || appErr.Code != tc.want.Code {
+				t.Fatalf("error = %v, want %v", err, tc.want)
+			}
+			if _, ok := appErr.Details.(map[string]time.Time); ok != tc.hasUntil {
+				t.Errorf("until in details = %v, want %v", ok, tc.hasUntil)
+			}
+		})
+	}
+}
+
+// fakeStatusUsers records status changes made through the transaction
+type fakeStatusUsers struct {
+	repository.IUserRepository
+	user    *model.User
+	changes []*model.StatusChange
+}
+
+func (r *fakeStatusUsers) FindByUUID(_ context.Context, uuid string) (*model.User, error) {
+	if uuid != r.user.UUID {
+		return nil, apperr.ErrNotFound
+	}
+	u := *r.user
+	return &u, nil
+}
+
+func (r *fakeStatusUsers) WithTransaction(_ context.Context, fn func(tx *sql.Tx) error) error {
+	return fn(nil)
+}
+
+func (r *fakeStatusUsers) SetStatusTx(_ context.Context, _ *sql.Tx, _ int64, from, to int, until *time.Time) error {
+	if r.user.Status != from {
+		return apperr.ErrInvalidStatusTransition
+	}
+	r.user.Status, r.user.StatusUntil = to, until
+	return nil
+}
+
+func (r *fakeStatusUsers) AddStatusChangeTx(_ context.Context, _ *sql.Tx, c *model.StatusChange) error {
+	r.changes = append(r.changes, c)
+	return nil
+}
+
+func TestAdminChangeUserStatusHandler(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name    string
+		status  int
+		target  string
+		body    string
+		want    int
+		changed bool
+	}{
+		{name: "lift a ban", status: constants.UserStatusBanned, target: "u-2",
+			body: `{"status":1,"reason":"Appeal accepted"}`, want: http.StatusOK, changed: true},
+		{name: "transition not allowed", status: constants.UserStatusPending, target: "u-2",
+			body: `{"status":4,"reason":"Spam reports"}`, want: http.StatusConflict},
+		{name: "pending cannot be set", status: constants.UserStatusActive, target: "u-2",
+			body: `{"status":2,"reason":"Spam reports"}`, want: http.StatusBadRequest},
+		{name: "own account", status: constants.UserStatusActive, target: "u-1",
+			body: `{"status":3,"reason":"Spam reports"}`, want: http.StatusForbidden},
+		{name: "expiry on a permanent status", status: constants.UserStatusActive, target: "u-2",
+			body: `{"status":0,"reason":"Spam reports","expires_at":"` + future + `"}`, want: http.StatusBadRequest},
+		{name: "expiry in the past", status: constants.UserStatusActive, target: "u-2",
+			body: `{"status":4,"reason":"Spam reports","expires_at":"2020-01-01T00:00:00Z"}`, want: http.StatusBadRequest},
+		{name: "reason missing", status: constants.UserStatusBanned, target: "u-2",
+			body: `{"status":1}`, want: http.StatusBadRequest},
+		{name: "unknown user", status: constants.UserStatusBanned, target: "u-9",
+			body: `{"status":1,"reason":"Appeal accepted"}`, want: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			users := &fakeStatusUsers{user: &model.User{ID: 2, UUID: "u-2", Status: tc.status}}
+			admin := &model.User{ID: 1, UUID: "u-1", Status: constants.UserStatusActive}
+			if tc.target == admin.UUID {
+				users.user = admin
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/users/"+tc.target+"/status", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.SetPathValue("uuid", tc.target)
+			r = r.WithContext(context.WithValue(r.Context(), constants.UserContextKey, &utils.Claims{UserID: 1, UUID: "u-1"}))
+			w := httptest.NewRecorder()
+
+			AdminChangeUserStatusHandler(users, nil).ServeHTTP(w, r)
+
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.want, w.Body)
+			}
+			if changed := len(users.changes) > 0; changed != tc.changed {
+				t.Fatalf("history written = %v, want %v", changed, tc.changed)
+			}
+			if tc.changed {
+				c := users.changes[0]
+				if c.FromStatus != tc.status || c.ToStatus != users.user.Status || *c.ChangedByID != 1 {
+					t.Errorf("history = %+v", c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/mailer"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+)
+
+// issueOTP generates a 6 digit code for purpose, stores its hash and returns
+// the plain code so it can be mailed. Older codes for the same purpose stop working.
+func issueOTP(ctx context.Context, userTokens repository.IUserTokenRepository, userID int64, purpose, target string, ttl time.Duration) (string, error) {
+	code, err := utils.OTP(6)
+	if err != nil {
+		return "", err
+	}
+
+	err = userTokens.Create(ctx, &model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(code),
+		Target:    target,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return code, err
+}
+
+// verifyOTP checks code against the user's active token for purpose. Every
+// guess is claimed before the comparison and the token is burned once
+// MaxOTPAttempts are used up.
+// The token is not consumed here; callers do that together with their update.
+func verifyOTP(ctx context.Context, userTokens repository.IUserTokenRepository, userID int64, purpose, code string) (*model.UserToken, error) {
+	t, err := userTokens.FindActive(ctx, userID, purpose)
+	if err == apperr.ErrNotFound {
+		return nil, apperr.ErrInvalidCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := userTokens.ClaimAttempt(ctx, t.ID, constants.MaxOTPAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		_ = userTokens.Invalidate(ctx, t.ID)
+		return nil, apperr.ErrInvalidCode
+	}
+
+	if !utils.CompareTokenHash(t.TokenHash, code) {
+		if t.Attempts+1 >= constants.MaxOTPAttempts {
+			_ = userTokens.Invalidate(ctx, t.ID)
+		}
+		return nil, apperr.ErrInvalidCode
+	}
+
+	return t, nil
+}
+
+// sendLimited reports whether the user was already sent limit tokens for
+// purpose within window. Callers then drop the request and answer as if the
+// mail went out, since a 429 would reveal that the account exists.
+func sendLimited(ctx context.Context, userTokens repository.IUserTokenRepository, userID int64, purpose string, limit int, window time.Duration) (bool, error) {
+	count, err := userTokens.CountSince(ctx, userID, purpose, time.Now().Add(-window))
+	if err != nil {
+		return false, err
+	}
+	if count < limit {
+		return false, nil
+	}
+
+	slog.Warn("send_rate_limited", "user_id", userID, "purpose", purpose)
+	return true, nil
+}
+
+// deliver sends mail in the background so response time does not reveal
+// whether an account exists; failures are only logged.
+func deliver(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := mailer.Default().Send(ctx, msg); err != nil {
+			slog.Error("mail_send_failed", "to", msg.To, "subject", msg.Subject, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/utils"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+)
+
+// fakeUserTokens holds a single token and mirrors the conditional updates of
+// UserTokenRepository under a lock, the way the database serialises them
+type fakeUserTokens struct {
+	repository.IUserTokenRepository
+
+	mu    sync.Mutex
+	token model.UserToken
+	used  bool
+}
+
+func (r *fakeUserTokens) FindActive(context.Context, int64, string) (*model.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used {
+		return nil, apperr.ErrNotFound
+	}
+	t := r.token
+	return &t, nil
+}
+
+func (r *fakeUserTokens) ClaimAttempt(_ context.Context, _ int64, max int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.used || r.token.Attempts >= max {
+		return false, nil
+	}
+	r.token.Attempts++
+	return true, nil
+}
+
+func (r *fakeUserTokens) Invalidate(context.Context, int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.used = true
+	return nil
+}
+
+func TestVerifyOTP(t *testing.T) {
+	ctx := context.Background()
+	newRepo := func() *fakeUserTokens {
+		return &fakeUserTokens{token: model.UserToken{ID: 1, UserID: 1, TokenHash: utils.HashToken("123456")}}
+	}
+
+	t.Run("right code", func(t *testing.T) {
+		if _, err := verifyOTP(ctx, newRepo(), 1, "p", "123456"); err != nil {
+			t.Fatalf("verifyOTP: %v", err)
+		}
+	})
+
+	t.Run("burned after the last wrong guess", func(t *testing.T) {
+		repo := newRepo()
+		for i := range constants.MaxOTPAttempts {
+			if _, err := verifyOTP(ctx, repo, 1, "p", "000000"); err != apperr.ErrInvalidCode {
+				t.Fatalf("guess %d: error = %v, want ErrInvalidCode", i+1, err)
+			}
+		}
+		if _, err := verifyOTP(ctx, repo, 1, "p", "123456"); err != apperr.ErrInvalidCode {
+			t.Fatalf("right code after the limit: error = %v, want ErrInvalidCode", err)
+		}
+		if !repo.used {
+			t.Error("token not burned")
+		}
+	})
+
+	t.Run("concurrent guesses share the limit", func(t *testing.T) {
+		repo := newRepo()
+
+		var wg sync.WaitGroup
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = verifyOTP(ctx, repo, 1, "p", "000000")
+			}()
+		}
+		wg.Wait()
+
+		if repo.token.Attempts > constants.MaxOTPAttempts {
+			t.Errorf("attempts = %d, want at most %d", repo.token.Attempts, constants.MaxOTPAttempts)
+		}
+		if _, err := verifyOTP(ctx, repo, 1, "p", "123456"); err != apperr.ErrInvalidCode {
+			t.Fatalf("right code after the limit: error = %v, want ErrInvalidCode", err)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/lakhan-purohit/net-http/internal/pkg/apperr"
+	"github.com/lakhan-purohit/net-http/internal/pkg/config"
+	"github.com/lakhan-purohit/net-http/internal/pkg/constants"
+	"github.com/lakhan-purohit/net-http/internal/pkg/mailer"
+	"github.com/lakhan-purohit/net-http/internal/pkg/request"
+	"github.com/lakhan-purohit/net-http/internal/pkg/response"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/model"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/repository"
+	"github.com/lakhan-purohit/net-http/internal/rest-api/schema"
+)
+
+// @Summary Verify email
+// @Description Activates a pending account with the code sent at sign-up.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.VerifyEmailRequest true "Email and verification code"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/verify-email [post]
+func VerifyEmailHandler(repo repository.IAuthRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.VerifyEmailRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		user, err := repo.FindByEmail(r.Context(), req.Email)
+		if err == apperr.ErrNotFound {
+			response.Error(w, apperr.ErrInvalidCode)
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		t, err := verifyOTP(r.Context(), userTokens, user.ID, constants.TokenPurposeEmailVerification, req.Code)
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		if err := repo.VerifyEmail(r.Context(), user.ID, t.ID); err != nil {
+			if err == apperr.ErrInvalidToken {
+				err = apperr.ErrInvalidCode
+			}
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{
+			W:       w,
+			Message: "Email verified, you can now log in",
+		})
+	}
+}
+
+// @Summary Resend verification code
+// @Description Sends a new verification code to a pending account, at most once per cooldown period. The response is the same whether or not a code was sent.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body schema.ResendVerificationRequest true "Account email"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Router /api/v1/public/auth/resend-verification [post]
+func ResendVerificationHandler(repo repository.IAuthRepository, userTokens repository.IUserTokenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var req schema.ResendVerificationRequest
+
+		if err := request.Bind(r, &req); err != nil {
+			response.BadRequest(response.SendParams{
+				W:       w,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		const sent = "If the account is awaiting verification, a new code has been sent"
+
+		user, err := repo.FindByEmail(r.Context(), req.Email)
+		if err == apperr.ErrNotFound {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+		if err != nil {
+			response.Error(w, err)
+			return
+		}
+		if user.Status != constants.UserStatusPending {
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+
+		// Cooldown is measured from the last code that is still usable
+		last, err := userTokens.FindActive(r.Context(), user.ID, constants.TokenPurposeEmailVerification)
+		if err != nil && err != apperr.ErrNotFound {
+			response.Error(w, err)
+			return
+		}
+		if last != nil && time.Since(last.CreatedAt) < config.Get().Auth.EmailVerificationCooldown {
+			slog.Warn("verification_resend_cooldown", "user_id", user.ID)
+			response.Success(response.SendParams{W: w, Message: sent})
+			return
+		}
+
+		if err := sendVerificationCode(r.Context(), userTokens, user); err != nil {
+			response.Error(w, err)
+			return
+		}
+
+		response.Success(response.SendParams{W: w, Message: sent})
+	}
+}
+
+// sendVerificationCode issues a fresh verification code and mails it to the user
+func sendVerificationCode(ctx context.Context, userTokens repository.IUserTokenRepository, user *model.User) error {
+	ttl := config.Get().Auth.EmailVerificationTTL
+
+	code, err := issueOTP(ctx, userTokens, user.ID, constants.TokenPurposeEmailVerification, user.Email, ttl)
+	if err != nil {
+		return err
+	}
+
+	deliver(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nYour verification code is %s. It expires in %s.\n",
+			user.Username, code, ttl,
+		),
+	})
+	return nil
+}
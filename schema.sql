@@ -11,9 +11,21 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(255) NOT NULL UNIQUE,
     password VARCHAR(255) NOT NULL,
     avatar VARCHAR(255) DEFAULT NULL,
-    status INT DEFAULT 1,
+    status INT DEFAULT 2, -- see constants.UserStatus* (new accounts start pending)
+    status_until TIMESTAMP NULL DEFAULT NULL, -- end of a temporary ban or suspension
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    locked_until TIMESTAMP NULL DEFAULT NULL,
+    totp_secret VARCHAR(64) DEFAULT NULL,
+    totp_enabled TINYINT(1) NOT NULL DEFAULT 0,
+    totp_last_step BIGINT DEFAULT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL, -- soft delete; purged after ACCOUNT_DELETED_RETENTION
+    INDEX idx_users_created (created_at, id),
+    INDEX idx_users_deleted (deleted_at),
+    INDEX idx_users_status_until (status_until),
+    INDEX idx_users_status (status, created_at),
+    FULLTEXT INDEX ft_users_search (username, email) -- user list q= search
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- User Statistics Table
@@ -23,3 +35,214 @@ CREATE TABLE IF NOT EXISTS user_stats (
     login_count INT DEFAULT 0,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Refresh Tokens Table (rotation & reuse detection)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    token_id VARCHAR(36) NOT NULL UNIQUE,
+    family_id VARCHAR(36) NOT NULL,
+    user_id BIGINT NOT NULL,
+    replaced_by VARCHAR(36) DEFAULT NULL,
+    user_agent VARCHAR(255) DEFAULT NULL,
+    ip_address VARCHAR(45) DEFAULT NULL,
+    client_id VARCHAR(64) DEFAULT NULL, -- set for OAuth (delegated) sessions
+    scope VARCHAR(500) DEFAULT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    revoked_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_refresh_tokens_family (family_id),
+    INDEX idx_refresh_tokens_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Revoked Access Tokens (rows are purged once the token would have expired)
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(36) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_revoked_tokens_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Per-user revocation cutoff ("logout everywhere")
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    user_id BIGINT PRIMARY KEY,
+    revoked_before TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Revoked sessions (sid), so a logged out device's access token stops at once
+CREATE TABLE IF NOT EXISTS revoked_sessions (
+    session_id VARCHAR(36) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_revoked_sessions_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Login History Table (every attempt, successful or not)
+CREATE TABLE IF NOT EXISTS login_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT DEFAULT NULL,
+    email VARCHAR(255) NOT NULL,
+    success TINYINT(1) NOT NULL,
+    failure_reason VARCHAR(50) DEFAULT NULL,
+    ip_address VARCHAR(45) DEFAULT NULL,
+    user_agent VARCHAR(255) DEFAULT NULL,
+    request_id VARCHAR(64) DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_login_events_user (user_id, created_at),
+    INDEX idx_login_events_email (email, created_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Failed Login Counters (per account & per IP, used when LOGIN_ATTEMPT_STORE=mysql)
+CREATE TABLE IF NOT EXISTS login_attempts (
+    attempt_key VARCHAR(191) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure TIMESTAMP(3) NOT NULL,
+    INDEX idx_login_attempts_last_failure (last_failure)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- One-time User Tokens (password reset codes etc.), stored hashed
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    target VARCHAR(255) DEFAULT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_tokens_user (user_id, purpose, created_at),
+    INDEX idx_user_tokens_hash (token_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 2FA Recovery Codes (bcrypt hashed, single use)
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_recovery_codes_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- API Keys (x-api-key). Only the SHA-256 of the key is stored; prefix is the lookup handle.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    prefix CHAR(12) NOT NULL UNIQUE,
+    key_hash CHAR(64) NOT NULL,
+    owner_id BIGINT NOT NULL,
+    scopes VARCHAR(500) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NULL DEFAULT NULL,
+    last_used_at TIMESTAMP NULL DEFAULT NULL,
+    revoked_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_api_keys_owner (owner_id),
+    FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- OAuth2 Clients (partner apps). Public clients have no secret and must use PKCE.
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    client_id VARCHAR(64) NOT NULL UNIQUE,
+    secret_hash CHAR(64) DEFAULT NULL,
+    name VARCHAR(100) NOT NULL,
+    redirect_uris TEXT NOT NULL, -- space separated
+    scopes VARCHAR(500) NOT NULL DEFAULT '',
+    grant_types VARCHAR(200) NOT NULL DEFAULT '',
+    revoked_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- OAuth2 Authorization Codes (hashed, single use, short lived)
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    code_hash CHAR(64) NOT NULL UNIQUE,
+    client_id VARCHAR(64) NOT NULL,
+    user_id BIGINT NOT NULL,
+    redirect_uri VARCHAR(2000) NOT NULL,
+    scope VARCHAR(500) NOT NULL DEFAULT '',
+    code_challenge VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_oauth_codes_expires (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (client_id) REFERENCES oauth_clients(client_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- External (OIDC) identities linked to local users
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL, -- the provider's stable "sub"
+    email VARCHAR(255) DEFAULT NULL,
+    last_login_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_user_identities_subject (provider, subject),
+    INDEX idx_user_identities_user (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Started OIDC logins (state hashed, single use, short lived)
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    state_hash CHAR(64) NOT NULL UNIQUE,
+    provider VARCHAR(50) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_oidc_login_states_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Audit trail of privileged admin actions
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id BIGINT DEFAULT NULL,
+    action VARCHAR(50) NOT NULL, -- see constants.AuditAction*
+    target_user_id BIGINT DEFAULT NULL,
+    reason VARCHAR(500) DEFAULT NULL,
+    token_id VARCHAR(36) DEFAULT NULL, -- jti of a token issued by the action
+    ip_address VARCHAR(45) DEFAULT NULL,
+    user_agent VARCHAR(255) DEFAULT NULL,
+    request_id VARCHAR(64) DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_logs_actor (actor_id, created_at),
+    INDEX idx_audit_logs_target (target_user_id, created_at),
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (target_user_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Previous password hashes, for the "no reuse of the last N passwords" rule
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_password_history_user (user_id, id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Status changes made by admins, and temporary statuses running out
+CREATE TABLE IF NOT EXISTS user_status_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    from_status INT NOT NULL,
+    to_status INT NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    expires_at TIMESTAMP NULL DEFAULT NULL, -- when to_status ends by itself
+    changed_by BIGINT DEFAULT NULL, -- NULL when a temporary status expired
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_user_status_history_user (user_id, id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;